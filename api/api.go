@@ -0,0 +1,263 @@
+// Package api serves the episode store as a JSON HTTP API.
+//
+//	GET  /episodes          list episodes; ?limit, ?cursor, ?year, ?guest, ?q, ?episodes, ?kind
+//	GET  /episodes/{id}     one episode
+//	GET  /guests            every guest with their appearances and co-guests
+//	POST /search            search; {"query", "mode", "limit", "from", "to", "guest", "episodes", "kind"}
+//
+// Errors are returned as {"error": "message"} with a matching status code.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+	"webscraper/search"
+	"webscraper/store"
+)
+
+// Page size limits for GET /episodes and POST /search.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+type server struct {
+	store    store.EpisodeStore
+	embedder embedding.Embedder
+	keywords *keywordIndex
+}
+
+// NewHandler returns the API handler. embedder embeds search queries and
+// must use the model the stored episodes were embedded with.
+func NewHandler(s store.EpisodeStore, embedder embedding.Embedder) http.Handler {
+	srv := &server{store: s, embedder: embedder, keywords: &keywordIndex{store: s}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /episodes", srv.listEpisodes)
+	mux.HandleFunc("GET /episodes/{id}", srv.getEpisode)
+	mux.HandleFunc("GET /guests", srv.listGuests)
+	mux.HandleFunc("POST /search", srv.search)
+
+	// The same paths without a method catch every other method.
+	for _, path := range []string{"/episodes", "/episodes/{id}", "/guests", "/search"} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		})
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no route for %s", r.URL.Path))
+	})
+	return mux
+}
+
+type episodesResponse struct {
+	Episodes []scraper.Episode `json:"episodes"`
+	Next     string            `json:"next,omitempty"` // pass as ?cursor for the next page
+}
+
+func (s *server) listEpisodes(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, err := parseLimit(q.Get("limit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter, err := numberFilter(q.Get("episodes"), q.Get("kind"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter.Guest, filter.Text = q.Get("guest"), q.Get("q")
+	if year := q.Get("year"); year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil || y < 1 || y > 9999 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid year %q", year))
+			return
+		}
+		filter.From = time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+		filter.To = filter.From.AddDate(1, 0, 0).Add(-time.Nanosecond)
+	}
+
+	page, err := s.store.List(r.Context(), store.ListOptions{After: q.Get("cursor"), Limit: limit, Filter: filter})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp := episodesResponse{Episodes: make([]scraper.Episode, len(page.Episodes)), Next: page.Next}
+	for i, e := range page.Episodes {
+		resp.Episodes[i] = e.Episode
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) getEpisode(w http.ResponseWriter, r *http.Request) {
+	e, err := s.store.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e.Episode)
+}
+
+// listGuests returns the guest records rebuilt on every sync, most
+// appearances first. A store synced before guest records existed has none,
+// so they are derived from the episodes on the fly.
+func (s *server) listGuests(w http.ResponseWriter, r *http.Request) {
+	guests, err := s.store.Guests(r.Context())
+	if err == nil && len(guests) == 0 {
+		var episodes []store.Episode
+		err = store.ForEach(r.Context(), s.store, func(e store.Episode) error {
+			e.Embedding = nil // not needed to count guests
+			episodes = append(episodes, e)
+			return nil
+		})
+		guests = store.BuildGuests(episodes)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]store.Guest{"guests": guests})
+}
+
+type searchRequest struct {
+	Query    string `json:"query"`
+	Mode     string `json:"mode"` // hybrid (default), vector or keyword
+	Limit    int    `json:"limit"`
+	From     string `json:"from"` // YYYY-MM-DD, inclusive
+	To       string `json:"to"`   // YYYY-MM-DD, inclusive
+	Guest    string `json:"guest"`
+	Episodes string `json:"episodes"` // number range, e.g. "100-150"
+	Kind     string `json:"kind"`     // regular, special, rerun or compilation
+}
+
+// numberFilter returns a filter on an episode number range such as
+// "100-150" and an episode kind; both may be empty.
+func numberFilter(episodes, kind string) (store.Filter, error) {
+	f := store.Filter{Kind: kind}
+	if episodes != "" {
+		var err error
+		if f.NumberFrom, f.NumberTo, err = store.ParseNumberRange(episodes); err != nil {
+			return store.Filter{}, err
+		}
+	}
+	return f, store.CheckKind(kind)
+}
+
+type searchResult struct {
+	Score   float64         `json:"score"`
+	Episode scraper.Episode `json:"episode"`
+}
+
+func (s *server) search(w http.ResponseWriter, r *http.Request) {
+	var req searchRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, errors.New("query is required"))
+		return
+	}
+	if req.Limit < 0 || req.Limit > MaxLimit {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", MaxLimit))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultLimit
+	}
+	filter, err := numberFilter(req.Episodes, req.Kind)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter.Guest = req.Guest
+	for _, d := range []struct {
+		name, value string
+		t           *time.Time
+	}{{"from", req.From, &filter.From}, {"to", req.To, &filter.To}} {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", d.value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%s: want YYYY-MM-DD", d.name))
+			return
+		}
+		*d.t = t
+	}
+
+	opts := store.SearchOptions{Limit: req.Limit, Filter: filter}
+	var index *search.Index
+	switch req.Mode {
+	case "hybrid", "", "keyword":
+		if index, err = s.keywords.get(r.Context()); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	case "vector":
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown mode %q (want hybrid, vector or keyword)", req.Mode))
+		return
+	}
+	var matches []store.Match
+	switch req.Mode {
+	case "hybrid", "":
+		matches, err = search.Hybrid(r.Context(), s.store, s.embedder, req.Query, search.HybridOptions{SearchOptions: opts, Index: index})
+	case "vector":
+		matches, err = search.Semantic(r.Context(), s.store, s.embedder, req.Query, opts)
+	case "keyword":
+		matches, err = search.Keyword(r.Context(), s.store, req.Query, opts, index)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	results := make([]searchResult, len(matches))
+	for i, m := range matches {
+		results[i] = searchResult{Score: m.Score, Episode: m.Episode.Episode}
+	}
+	writeJSON(w, http.StatusOK, map[string][]searchResult{"results": results})
+}
+
+func parseLimit(s string) (int, error) {
+	if s == "" {
+		return DefaultLimit, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > MaxLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+	return n, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError sends err as a JSON error. Server-side failures are logged
+// and reported only by their status text, so database and embedding API
+// errors don't leak to clients.
+func writeError(w http.ResponseWriter, status int, err error) {
+	msg := err.Error()
+	if status >= 500 {
+		fmt.Fprintf(os.Stderr, "❌ %d: %v\n", status, err)
+		msg = http.StatusText(status)
+	}
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+	"webscraper/store"
+	"webscraper/store/storetest"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	embedder := embedding.NewFake(64)
+	episodes := []store.Episode{
+		{Episode: scraper.Episode{ID: "a", EpisodeNo: "1", Title: "Yacht Rock Summit", Guests: []string{"Jake Longstreth"},
+			Timestamp: time.Date(2015, 4, 19, 0, 0, 0, 0, time.UTC)}},
+		{Episode: scraper.Episode{ID: "b", EpisodeNo: "2", Title: "Corporate Rock", Guests: []string{"Jake Longstreth", "Seth Rogen"},
+			Timestamp: time.Date(2016, 1, 10, 0, 0, 0, 0, time.UTC)}},
+		{Episode: scraper.Episode{ID: "c", EpisodeNo: "3 (Rerun)", Title: "The Crisis Continues", Guests: []string{"Jonah Hill"},
+			Timestamp: time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	for i := range episodes {
+		episodes[i].Numbering = scraper.ParseEpisodeNo(episodes[i].EpisodeNo)
+	}
+	srv := httptest.NewServer(NewHandler(storetest.Seed(t, embedder, episodes), embedder))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// do sends a request and decodes the JSON response into out.
+func do(t *testing.T, method, url, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%s %s: Content-Type %q", method, url, ct)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("%s %s: decode: %v", method, url, err)
+	}
+	return resp.StatusCode
+}
+
+func TestListEpisodes(t *testing.T) {
+	srv := newTestServer(t)
+
+	var page episodesResponse
+	if code := do(t, "GET", srv.URL+"/episodes?limit=2", "", &page); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(page.Episodes) != 2 || page.Next == "" {
+		t.Fatalf("first page: %+v", page)
+	}
+	var rest episodesResponse
+	do(t, "GET", srv.URL+"/episodes?limit=2&cursor="+page.Next, "", &rest)
+	if len(rest.Episodes) != 1 || rest.Next != "" {
+		t.Fatalf("second page: %+v", rest)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"year=2016", []string{"b", "c"}},
+		{"guest=longstreth", []string{"a", "b"}},
+		{"q=rock&year=2016", []string{"b"}},
+		{"episodes=2-150", []string{"b", "c"}},
+		{"episodes=1-150&kind=regular", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		var got episodesResponse
+		do(t, "GET", srv.URL+"/episodes?"+tt.query, "", &got)
+		var ids []string
+		for _, e := range got.Episodes {
+			ids = append(ids, e.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, ids, tt.want)
+		}
+	}
+}
+
+func TestGetEpisode(t *testing.T) {
+	srv := newTestServer(t)
+
+	var e map[string]interface{}
+	if code := do(t, "GET", srv.URL+"/episodes/b", "", &e); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if e["title"] != "Corporate Rock" {
+		t.Errorf("got %v", e)
+	}
+	if _, ok := e["embedding"]; ok {
+		t.Error("response includes the embedding")
+	}
+
+	var errResp map[string]string
+	if code := do(t, "GET", srv.URL+"/episodes/missing", "", &errResp); code != http.StatusNotFound || errResp["error"] == "" {
+		t.Errorf("missing episode: %d %v", code, errResp)
+	}
+}
+
+func TestGuests(t *testing.T) {
+	srv := newTestServer(t)
+	var got struct{ Guests []store.Guest }
+	do(t, "GET", srv.URL+"/guests", "", &got)
+
+	var names []string
+	for _, g := range got.Guests {
+		names = append(names, fmt.Sprintf("%s:%d", g.ID, g.Appearances))
+	}
+	want := []string{"jake-longstreth:2", "jonah-hill:1", "seth-rogen:1"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+	if jake := got.Guests[0]; jake.First.EpisodeID != "a" || len(jake.CoGuests) != 1 || jake.CoGuests[0].ID != "seth-rogen" {
+		t.Errorf("jake: %+v", jake)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	srv := newTestServer(t)
+
+	var got struct{ Results []searchResult }
+	code := do(t, "POST", srv.URL+"/search", `{"query": "yacht rock summit", "mode": "vector", "limit": 1}`, &got)
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(got.Results) != 1 || got.Results[0].Episode.ID != "a" {
+		t.Errorf("got %+v", got.Results)
+	}
+
+	got.Results = nil
+	do(t, "POST", srv.URL+"/search", `{"query": "rock", "from": "2016-01-01", "guest": "rogen"}`, &got)
+	if len(got.Results) != 1 || got.Results[0].Episode.ID != "b" {
+		t.Errorf("filtered: got %+v", got.Results)
+	}
+
+	got.Results = nil
+	do(t, "POST", srv.URL+"/search", `{"query": "crisis rock", "mode": "keyword", "kind": "rerun"}`, &got)
+	if len(got.Results) != 1 || got.Results[0].Episode.ID != "c" {
+		t.Errorf("kind: got %+v", got.Results)
+	}
+
+	got.Results = nil
+	do(t, "POST", srv.URL+"/search", `{"query": "Jonah", "mode": "keyword"}`, &got)
+	if len(got.Results) != 1 || got.Results[0].Episode.ID != "c" {
+		t.Errorf("keyword: got %+v", got.Results)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv := newTestServer(t)
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{"GET", "/episodes?limit=0", "", http.StatusBadRequest},
+		{"GET", "/episodes?year=soon", "", http.StatusBadRequest},
+		{"GET", "/episodes?episodes=150-100", "", http.StatusBadRequest},
+		{"GET", "/episodes?kind=bonus", "", http.StatusBadRequest},
+		{"POST", "/search", `{"query": "x", "episodes": "lots"}`, http.StatusBadRequest},
+		{"POST", "/search", `{"query": ""}`, http.StatusBadRequest},
+		{"POST", "/search", `{"query": "x", "from": "May 2015"}`, http.StatusBadRequest},
+		{"POST", "/search", `not json`, http.StatusBadRequest},
+		{"POST", "/search", `{"query": "x", "mode": "fuzzy"}`, http.StatusBadRequest},
+		{"DELETE", "/episodes/a", "", http.StatusMethodNotAllowed},
+		{"GET", "/search", "", http.StatusMethodNotAllowed},
+		{"GET", "/nope", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		var resp map[string]string
+		code := do(t, tt.method, srv.URL+tt.path, tt.body, &resp)
+		if code != tt.want || resp["error"] == "" {
+			t.Errorf("%s %s: got %d %v, want %d with an error", tt.method, tt.path, code, resp, tt.want)
+		}
+	}
+}
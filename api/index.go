@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"webscraper/search"
+	"webscraper/store"
+)
+
+// indexCheckInterval is how often keywordIndex checks whether a sync
+// changed the stored episodes.
+const indexCheckInterval = time.Minute
+
+// keywordIndex caches the BM25 index that keyword and hybrid search use on
+// stores without a text index of their own, so requests don't load every
+// episode. It is rebuilt only when the stored content changed, as after a
+// sync, which is checked from the content hashes at most once every
+// indexCheckInterval.
+type keywordIndex struct {
+	store store.EpisodeStore
+
+	mu          sync.Mutex
+	index       *search.Index
+	fingerprint string
+	checked     time.Time
+}
+
+// get returns the index, or nil when the store searches text itself.
+func (k *keywordIndex) get(ctx context.Context) (*search.Index, error) {
+	if _, ok := k.store.(store.TextSearcher); ok {
+		return nil, nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.index != nil && time.Since(k.checked) < indexCheckInterval {
+		return k.index, nil
+	}
+
+	fingerprint, err := contentFingerprint(ctx, k.store)
+	if err != nil {
+		return nil, err
+	}
+	if k.index == nil || fingerprint != k.fingerprint {
+		index, err := search.BuildIndex(ctx, k.store)
+		if err != nil {
+			return nil, err
+		}
+		k.index, k.fingerprint = index, fingerprint
+	}
+	k.checked = time.Now()
+	return k.index, nil
+}
+
+// contentFingerprint identifies the stored episodes and their content.
+func contentFingerprint(ctx context.Context, s store.EpisodeStore) (string, error) {
+	hashes, err := s.Hashes(ctx)
+	if err != nil {
+		return "", err
+	}
+	ids := make([]string, 0, len(hashes))
+	for id := range hashes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	h := sha256.New()
+	for _, id := range ids {
+		h.Write([]byte(id + "\x00" + hashes[id].ContentHash + "\x00"))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
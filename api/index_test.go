@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"webscraper/scraper"
+	"webscraper/store"
+)
+
+// listCounter counts full scans of a store.
+type listCounter struct {
+	store.EpisodeStore
+	lists int
+}
+
+func (c *listCounter) List(ctx context.Context, opts store.ListOptions) (store.Page, error) {
+	c.lists++
+	return c.EpisodeStore.List(ctx, opts)
+}
+
+func TestKeywordIndexRebuildsOnlyAfterSync(t *testing.T) {
+	ctx := context.Background()
+	mem := store.NewMemory()
+	if _, err := mem.Upsert(ctx, []store.Episode{{Episode: scraper.Episode{ID: "a", Title: "Yacht Rock"}, ContentHash: "1"}}); err != nil {
+		t.Fatal(err)
+	}
+	s := &listCounter{EpisodeStore: mem}
+	k := &keywordIndex{store: s}
+
+	search := func(query string) int {
+		t.Helper()
+		index, err := k.get(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(index.Search(query, store.SearchOptions{Limit: 10}))
+	}
+
+	if search("yacht") != 1 || search("yacht") != 1 {
+		t.Fatal("episode not found")
+	}
+	if s.lists != 1 {
+		t.Errorf("built the index with %d scans for two searches, want 1", s.lists)
+	}
+
+	// Past the check interval, an unchanged store keeps the index.
+	k.checked = time.Time{}
+	search("yacht")
+	if s.lists != 1 {
+		t.Errorf("rebuilt the index of an unchanged store")
+	}
+
+	// After a sync changes the content, the next check rebuilds it.
+	if _, err := mem.Upsert(ctx, []store.Episode{{Episode: scraper.Episode{ID: "a", Title: "Corporate Rock"}, ContentHash: "2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if search("corporate") != 0 {
+		t.Error("rebuilt the index before the check interval passed")
+	}
+	k.checked = time.Time{}
+	if search("corporate") != 1 {
+		t.Error("index not rebuilt after the content changed")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+
+	"webscraper/ask"
+	"webscraper/retry"
+)
+
+func runAsk(ctx context.Context, args []string) error {
+	fs := newFlagSet("ask", "<question>", `
+Answer a question about the podcast from the stored episodes. The -k
+episodes nearest to the question are retrieved by vector similarity and
+given to a chat model, which is told to answer only from them and cite
+them by their number in the prompt, like [2]. The cited episodes are
+printed with their URLs.
+
+With -stub no chat model is called; the retrieved context is printed
+instead.`)
+	storeCfg := addStoreFlags(fs)
+	embedderCfg := addEmbedderFlags(fs)
+	retryPolicy := addRetryFlags(fs)
+	k := fs.Int("k", ask.DefaultK, "number of episodes to retrieve")
+	filter := addFilterFlags(fs)
+	chatModel := fs.String("chat-model", envOr("CHAT_MODEL", ask.DefaultChatModel), "chat completion model (env CHAT_MODEL)")
+	chatURL := fs.String("chat-url", os.Getenv("CHAT_URL"), "base URL of an OpenAI-compatible chat server; empty uses OpenAI (env CHAT_URL)")
+	stub := fs.Bool("stub", false, "print the retrieved context instead of calling a chat model")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	question := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if question == "" {
+		fs.Usage()
+		return fmt.Errorf("missing question")
+	}
+
+	var completer ask.Completer
+	if !*stub {
+		var err error
+		if completer, err = newCompleter(*chatModel, *chatURL, retryPolicy); err != nil {
+			return err
+		}
+	}
+
+	episodeStore, err := storeCfg.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer episodeStore.Close(context.Background())
+
+	embedder, err := embedderCfg.new(retryPolicy)
+	if err != nil {
+		return err
+	}
+
+	answer, err := ask.Ask(ctx, episodeStore, embedder, completer, question, ask.Options{K: *k, Filter: *filter})
+	if err != nil {
+		return err
+	}
+
+	var sources []int
+	heading := "Sources:"
+	if completer == nil {
+		fmt.Print("Context (no chat model called):\n\n")
+	} else {
+		sources = answer.Cited()
+	}
+	if len(sources) == 0 {
+		// Nothing cited (or no model): show everything that was retrieved.
+		heading = "Retrieved episodes:"
+		for n := range answer.Sources {
+			sources = append(sources, n+1)
+		}
+	}
+	fmt.Println(strings.TrimSpace(answer.Text))
+	if len(sources) > 0 {
+		fmt.Println()
+		fmt.Println(heading)
+		for _, n := range sources {
+			e := answer.Sources[n-1].Episode
+			fmt.Printf("  [%d] #%s %s (%s) %s\n", n, e.EpisodeNo, e.Title, e.Date, e.Url)
+		}
+	}
+	return nil
+}
+
+// newCompleter returns a chat client for model on OpenAI, or on the
+// OpenAI-compatible server at baseURL, sending requests under the retry
+// policy.
+func newCompleter(model, baseURL string, policy *retry.Policy) (ask.Completer, error) {
+	key := os.Getenv("OPENAI_API_KEY")
+	if key == "" && baseURL == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY not set (or use -stub)")
+	}
+	config := openai.DefaultConfig(key)
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	config.HTTPClient = &http.Client{Transport: policy.Transport(nil)}
+	return ask.NewOpenAI(openai.NewClientWithConfig(config), model), nil
+}
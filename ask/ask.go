@@ -0,0 +1,184 @@
+// Package ask answers questions about the podcast from the stored episodes:
+// it retrieves the episodes nearest to the question and asks a chat model
+// to answer from them alone, citing them by their position in the prompt.
+package ask
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+
+	"webscraper/embedding"
+	"webscraper/search"
+	"webscraper/store"
+)
+
+// DefaultChatModel answers when no model is configured.
+const DefaultChatModel = openai.GPT4oMini
+
+// DefaultK is how many episodes are retrieved by default.
+const DefaultK = 5
+
+// Completer sends a system and user prompt to a chat model and returns its
+// reply.
+type Completer interface {
+	Complete(ctx context.Context, system, user string) (string, error)
+
+	// Model names the chat model, for display.
+	Model() string
+}
+
+// Options configures Ask.
+type Options struct {
+	// K is the number of episodes retrieved as context. Defaults to DefaultK.
+	K int
+
+	// Filter restricts which episodes can be retrieved.
+	Filter store.Filter
+}
+
+// Answer is the reply to a question and the episodes it was grounded in.
+type Answer struct {
+	Text    string
+	Sources []store.Match // in the order they appear in the prompt
+}
+
+// citationPattern matches a citation such as [2] or [1, 3].
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// Cited returns the numbers of the sources the answer cites, counting from
+// 1 as in the prompt, in prompt order. Sources are cited by number rather
+// than by episode number, which is blank or shared by specials.
+func (a Answer) Cited() []int {
+	cited := make([]bool, len(a.Sources)+1)
+	for _, m := range citationPattern.FindAllStringSubmatch(a.Text, -1) {
+		for _, f := range strings.Split(m[1], ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(f)); err == nil && n >= 1 && n <= len(a.Sources) {
+				cited[n] = true
+			}
+		}
+	}
+	var numbers []int
+	for n := 1; n <= len(a.Sources); n++ {
+		if cited[n] {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+// Ask retrieves the episodes nearest to question and has completer answer
+// from them. With a nil completer no model is called and Text is the
+// context that would have been sent, which is useful for checking
+// retrieval.
+func Ask(ctx context.Context, s store.EpisodeStore, embedder embedding.Embedder, completer Completer, question string, opts Options) (Answer, error) {
+	k := opts.K
+	if k <= 0 {
+		k = DefaultK
+	}
+	matches, err := search.Semantic(ctx, s, embedder, question, store.SearchOptions{Limit: k, Filter: opts.Filter})
+	if err != nil {
+		return Answer{}, err
+	}
+
+	// Chronological order makes "first" and "last" questions answerable.
+	// Undated episodes go last so they aren't taken for the oldest.
+	sort.SliceStable(matches, func(i, j int) bool {
+		return store.AppearsBefore(matches[i].Episode, matches[j].Episode)
+	})
+	answer := Answer{Sources: matches}
+
+	prompt := UserPrompt(question, matches)
+	if completer == nil {
+		answer.Text = prompt
+		return answer, nil
+	}
+	if len(matches) == 0 {
+		answer.Text = "No stored episodes matched the question."
+		return answer, nil
+	}
+	answer.Text, err = completer.Complete(ctx, SystemPrompt, prompt)
+	if err != nil {
+		return Answer{}, fmt.Errorf("chat completion: %w", err)
+	}
+	return answer, nil
+}
+
+// SystemPrompt instructs the model to stay within the retrieved episodes.
+const SystemPrompt = `You answer questions about the Time Crisis podcast with Ezra Koenig.
+Use only the episodes listed in the user's message; do not rely on outside knowledge.
+Cite every episode you use by its source number in square brackets, like [2].
+If the episodes do not contain the answer, say that you don't know.
+Keep the answer short.`
+
+// UserPrompt lists the episodes, oldest first and undated ones last,
+// numbered from 1 for citations, followed by the question.
+func UserPrompt(question string, matches []store.Match) string {
+	var b strings.Builder
+	b.WriteString("Episodes:\n\n")
+	for i, m := range matches {
+		e := m.Episode
+		fmt.Fprintf(&b, "[%d] %s\n", i+1, e.Title)
+		if e.EpisodeNo != "" {
+			fmt.Fprintf(&b, "Episode: %s\n", e.EpisodeNo)
+		}
+		if e.Date != "" {
+			fmt.Fprintf(&b, "Date: %s\n", e.Date)
+		}
+		if len(e.Guests) > 0 {
+			fmt.Fprintf(&b, "Guests: %s\n", strings.Join(e.Guests, ", "))
+		}
+		if e.Notes != "" {
+			fmt.Fprintf(&b, "Notes: %s\n", e.Notes)
+		}
+		if e.Content != nil && e.Content.Episode.Topics != "" {
+			fmt.Fprintf(&b, "Topics: %s\n", e.Content.Episode.Topics)
+		}
+		if e.Url != "" {
+			fmt.Fprintf(&b, "URL: %s\n", e.Url)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Question: %s\n", strings.TrimSpace(question))
+	return b.String()
+}
+
+// OpenAI completes prompts with the OpenAI chat completions API, or any
+// server compatible with it.
+type OpenAI struct {
+	client *openai.Client
+	model  string
+}
+
+// NewOpenAI returns a Completer for model, DefaultChatModel if empty.
+func NewOpenAI(client *openai.Client, model string) *OpenAI {
+	if model == "" {
+		model = DefaultChatModel
+	}
+	return &OpenAI{client: client, model: model}
+}
+
+func (o *OpenAI) Model() string { return o.model }
+
+func (o *OpenAI) Complete(ctx context.Context, system, user string) (string, error) {
+	resp, err := o.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: o.model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: system},
+			{Role: openai.ChatMessageRoleUser, Content: user},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no choices in response")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,113 @@
+package ask
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+	"webscraper/store"
+	"webscraper/store/storetest"
+)
+
+// recorder is a Completer that remembers its prompts.
+type recorder struct {
+	system, user string
+}
+
+func (r *recorder) Model() string { return "recorder" }
+
+func (r *recorder) Complete(ctx context.Context, system, user string) (string, error) {
+	r.system, r.user = system, user
+	return "Jake Longstreth first appeared in [1].", nil
+}
+
+func seed(t *testing.T, embedder embedding.Embedder) store.EpisodeStore {
+	t.Helper()
+	episodes := []store.Episode{
+		{Episode: scraper.Episode{ID: "b", EpisodeNo: "20", Title: "Jake Longstreth returns", Guests: []string{"Jake Longstreth"},
+			Date: "January 10, 2016", Timestamp: time.Date(2016, 1, 10, 0, 0, 0, 0, time.UTC), Url: "https://example.com/20"}},
+		{Episode: scraper.Episode{ID: "a", EpisodeNo: "1", Title: "Jake Longstreth debut", Guests: []string{"Jake Longstreth"},
+			Date: "April 19, 2015", Timestamp: time.Date(2015, 4, 19, 0, 0, 0, 0, time.UTC), Url: "https://example.com/1"}},
+		{Episode: scraper.Episode{ID: "c", EpisodeNo: "30", Title: "Unrelated", Guests: []string{"Jonah Hill"}}},
+		{Episode: scraper.Episode{ID: "d", EpisodeNo: "Special", Title: "Jake Longstreth live", Guests: []string{"Jake Longstreth"},
+			Date: "TBA", DateStatus: scraper.DateUnknown}},
+	}
+	return storetest.Seed(t, embedder, episodes)
+}
+
+func TestAskGroundsPrompt(t *testing.T) {
+	embedder := embedding.NewFake(64)
+	s := seed(t, embedder)
+	rec := &recorder{}
+
+	answer, err := Ask(context.Background(), s, embedder, rec, "When did Jake Longstreth first appear?", Options{K: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer.Text != "Jake Longstreth first appeared in [1]." {
+		t.Errorf("got answer %q", answer.Text)
+	}
+	if rec.system != SystemPrompt {
+		t.Error("system prompt not sent")
+	}
+	var order []string
+	for _, m := range answer.Sources {
+		order = append(order, m.Episode.EpisodeNo)
+	}
+	// The undated special goes last rather than first.
+	if want := []string{"1", "20", "Special"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("sources = %q, want %q: oldest first, undated last", order, want)
+	}
+	if cited := answer.Cited(); !reflect.DeepEqual(cited, []int{1}) || answer.Sources[0].Episode.ID != "a" {
+		t.Errorf("Cited: got %v, want source 1, episode #1", cited)
+	}
+	first, second := strings.Index(rec.user, "[1] Jake Longstreth debut\nEpisode: 1\n"), strings.Index(rec.user, "[2] Jake Longstreth returns\nEpisode: 20\n")
+	if first < 0 || second < 0 || first > second {
+		t.Errorf("episodes missing or out of order in prompt:\n%s", rec.user)
+	}
+	for _, want := range []string{"URL: https://example.com/1", "Date: April 19, 2015", "Question: When did Jake Longstreth first appear?"} {
+		if !strings.Contains(rec.user, want) {
+			t.Errorf("prompt missing %q:\n%s", want, rec.user)
+		}
+	}
+	if strings.Contains(rec.user, "Unrelated") {
+		t.Errorf("prompt includes an episode outside the top k:\n%s", rec.user)
+	}
+}
+
+func TestAskStub(t *testing.T) {
+	embedder := embedding.NewFake(64)
+	s := seed(t, embedder)
+
+	answer, err := Ask(context.Background(), s, embedder, nil, "Jake Longstreth", Options{K: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer.Text != UserPrompt("Jake Longstreth", answer.Sources) {
+		t.Errorf("stub mode should return the prompt, got:\n%s", answer.Text)
+	}
+}
+
+func TestCitedTellsSpecialsApart(t *testing.T) {
+	special := func(id string) store.Match {
+		return store.Match{Episode: store.Episode{Episode: scraper.Episode{ID: id, EpisodeNo: "Special"}}}
+	}
+	sources := []store.Match{special("a"), special("b"), special("c"), {Episode: store.Episode{Episode: scraper.Episode{ID: "d"}}}}
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"Only the second one [2].", []int{2}},
+		{"Both [3] and [1, 4].", []int{1, 3, 4}},
+		{"Out of range [5], [0] and [#].", nil},
+	}
+	for _, tt := range tests {
+		if got := (Answer{Text: tt.text, Sources: sources}).Cited(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Cited(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
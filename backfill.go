@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"webscraper/embedding"
+	"webscraper/store"
+)
+
+func runBackfillTimestamps(ctx context.Context, args []string) error {
+	fs := newFlagSet("backfill-timestamps", "", `
+Parse the date of every stored episode and save it as the timestamp field,
+with its precision. Episodes whose date cannot be parsed are marked with
+date_status "unknown".`)
+	storeCfg := addStoreFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	episodeStore, err := storeCfg.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer episodeStore.Close(context.Background())
+
+	var updated []store.Episode
+	err = store.ForEach(ctx, episodeStore, func(episode store.Episode) error {
+		if err := episode.SetDate(episode.Date); err != nil {
+			fmt.Printf("⚠️ Marking date of %v as unknown: %v\n", episode.ID, err)
+		}
+		updated = append(updated, episode)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write after iterating so no page of the listing sees its own updates.
+	for _, episode := range updated {
+		if _, err := episodeStore.Upsert(ctx, []store.Episode{episode}); err != nil {
+			fmt.Printf("Failed to update episode %v: %v\n", episode.ID, err)
+			continue
+		}
+		fmt.Printf("Updated episode %v - %v with %v timestamp %v\n", episode.ID, episode.Title, episode.Date, episode.Timestamp)
+	}
+	return nil
+}
+
+func runBackfillDates(ctx context.Context, args []string) error {
+	fs := newFlagSet("backfill-dates", "", `
+Convert the date of every stored episode to ISO 8601, save it as
+formatted_date and regenerate the embedding so it includes that date.`)
+	storeCfg := addStoreFlags(fs)
+	embed := fs.Bool("embed", true, "regenerate the embedding with the formatted date")
+	embedderCfg := addEmbedderFlags(fs)
+	retryPolicy := addRetryFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	episodeStore, err := storeCfg.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer episodeStore.Close(context.Background())
+
+	var episodes []store.Episode
+	err = store.ForEach(ctx, episodeStore, func(episode store.Episode) error {
+		// Convert Date to ISO 8601 at the precision it was written with.
+		if err := episode.SetDate(episode.Date); err != nil {
+			fmt.Printf("⚠️ Failed to format date for '%s': %v\n", episode.Title, err)
+		}
+		episodes = append(episodes, episode)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var texts []string
+	var vectors [][]float32
+	var embedder *embedding.Batcher
+	if *embed {
+		if embedder, err = embedderCfg.new(retryPolicy); err != nil {
+			return err
+		}
+		if texts, vectors, err = embedEpisodes(ctx, embedder, episodes); err != nil {
+			return err
+		}
+	}
+
+	for i, episode := range episodes {
+		if embedder != nil {
+			if vectors[i] == nil {
+				continue
+			}
+			episode.Embedding = vectors[i]
+			episode.EmbeddingHash = textHash(texts[i])
+			episode.EmbeddingModel = embedder.Model()
+		}
+
+		if _, err := episodeStore.Upsert(ctx, []store.Episode{episode}); err != nil {
+			fmt.Printf("❌ Failed to update episode %v: %v\n", episode.ID, err)
+			continue
+		}
+
+		fmt.Printf("✅ Updated episode %v - '%s' with formatted date\n", episode.ID, episode.Title)
+	}
+	return nil
+}
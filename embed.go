@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"webscraper/embedding"
+	"webscraper/store"
+)
+
+func runEmbed(ctx context.Context, args []string) error {
+	fs := newFlagSet("embed", "", `
+Generate a vector embedding for stored episodes and save it on each
+episode. By default every episode is re-embedded.`)
+	storeCfg := addStoreFlags(fs)
+	embedderCfg := addEmbedderFlags(fs)
+	retryPolicy := addRetryFlags(fs)
+	missing := fs.Bool("missing", false, "only embed episodes that have no embedding yet")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	episodeStore, err := storeCfg.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer episodeStore.Close(context.Background())
+
+	embedder, err := embedderCfg.new(retryPolicy)
+	if err != nil {
+		return err
+	}
+
+	var episodes []store.Episode
+	err = store.ForEach(ctx, episodeStore, func(episode store.Episode) error {
+		if !*missing || len(episode.Embedding) == 0 {
+			episodes = append(episodes, episode)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	texts, vectors, err := embedEpisodes(ctx, embedder, episodes)
+	if err != nil {
+		return err
+	}
+
+	for i, episode := range episodes {
+		if vectors[i] == nil {
+			continue
+		}
+
+		err := episodeStore.SetEmbedding(ctx, episode.ID, store.Embedding{
+			Vector: vectors[i],
+			Hash:   textHash(texts[i]),
+			Model:  embedder.Model(),
+		})
+		if err != nil {
+			fmt.Printf("❌ Failed to update episode %v: %v\n", episode.ID, err)
+			continue
+		}
+
+		fmt.Printf("✅ Updated episode %v - '%s' with vector embedding\n", episode.ID, episode.Title)
+	}
+	fmt.Printf("Embedded %d episodes in %d requests (HTTP %s).\n", len(episodes), embedder.Requests(), retryPolicy.Stats)
+	return nil
+}
+
+// embedEpisodes embeds the embeddingText of every episode in batches. The
+// vector of an episode that could not be embedded is nil and its failure
+// is printed; only a fatal error such as cancellation is returned.
+func embedEpisodes(ctx context.Context, embedder embedding.Embedder, episodes []store.Episode) ([]string, [][]float32, error) {
+	texts := make([]string, len(episodes))
+	for i, e := range episodes {
+		texts[i] = embeddingText(e)
+	}
+	if len(texts) == 0 {
+		return texts, nil, nil
+	}
+
+	vectors, err := embedder.Embed(ctx, texts)
+	var batchErr *embedding.BatchError
+	if err != nil && !errors.As(err, &batchErr) {
+		return nil, nil, err
+	}
+	if batchErr != nil {
+		for i, err := range batchErr.Failed {
+			fmt.Printf("❌ Failed to generate embedding for '%s': %v\n", episodes[i].Title, err)
+		}
+	}
+	return texts, vectors, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/sashabaranov/go-openai"
+
+	"webscraper/embedding"
+	"webscraper/retry"
+)
+
+// embedderConfig selects the embedding backend. Values default to the
+// EMBEDDER, EMBEDDING_* and OPENAI_API_KEY environment variables.
+type embedderConfig struct {
+	kind       string
+	model      string
+	url        string
+	dimensions int
+	batchItems int
+	batchToks  int
+}
+
+func addEmbedderFlags(fs *flag.FlagSet) *embedderConfig {
+	cfg := &embedderConfig{}
+	dimensions, _ := strconv.Atoi(os.Getenv("EMBEDDING_DIMENSIONS"))
+	fs.StringVar(&cfg.kind, "embedder", envOr("EMBEDDER", "openai"), "embedding backend: openai, http or fake (env EMBEDDER)")
+	fs.StringVar(&cfg.model, "embedding-model", os.Getenv("EMBEDDING_MODEL"), "embedding model; defaults to "+embedding.DefaultOpenAIModel+" for openai (env EMBEDDING_MODEL)")
+	fs.StringVar(&cfg.url, "embedding-url", os.Getenv("EMBEDDING_URL"), "base URL of an OpenAI-compatible server for -embedder http, e.g. http://localhost:11434/v1 (env EMBEDDING_URL)")
+	fs.IntVar(&cfg.dimensions, "embedding-dimensions", dimensions, "vector size; 0 keeps the model default (env EMBEDDING_DIMENSIONS)")
+	fs.IntVar(&cfg.batchItems, "embedding-batch-size", embedding.DefaultBatchItems, "maximum texts per embedding request")
+	fs.IntVar(&cfg.batchToks, "embedding-batch-tokens", embedding.DefaultBatchTokens, "maximum estimated tokens per embedding request")
+	return cfg
+}
+
+// new returns the configured embedder, batched, sending its HTTP requests
+// under the retry policy.
+func (cfg *embedderConfig) new(policy *retry.Policy) (*embedding.Batcher, error) {
+	e, err := cfg.backend(&http.Client{Transport: policy.Transport(nil)})
+	if err != nil {
+		return nil, err
+	}
+	return &embedding.Batcher{Embedder: e, MaxItems: cfg.batchItems, MaxTokens: cfg.batchToks}, nil
+}
+
+func (cfg *embedderConfig) backend(client *http.Client) (embedding.Embedder, error) {
+	switch cfg.kind {
+	case "openai":
+		key := os.Getenv("OPENAI_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY not set")
+		}
+		config := openai.DefaultConfig(key)
+		config.HTTPClient = client
+		return embedding.NewOpenAI(openai.NewClientWithConfig(config), cfg.model, cfg.dimensions), nil
+	case "http":
+		if cfg.url == "" || cfg.model == "" {
+			return nil, fmt.Errorf("-embedder http needs -embedding-url and -embedding-model")
+		}
+		e := embedding.NewHTTP(cfg.url, cfg.model, os.Getenv("EMBEDDING_API_KEY"))
+		e.Client = client
+		return e, nil
+	case "fake":
+		return embedding.NewFake(cfg.dimensions), nil
+	}
+	return nil, fmt.Errorf("unknown embedder %q (want openai, http or fake)", cfg.kind)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,191 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Default batch limits. OpenAI accepts up to 2048 inputs and roughly 300k
+// tokens per request; staying well below keeps requests fast to retry.
+const (
+	DefaultBatchItems  = 100
+	DefaultBatchTokens = 50000
+)
+
+// Batcher wraps an Embedder and splits large inputs into requests bounded
+// by item count and an estimated token budget. When a request is rejected
+// because of its input, the batch is split in half and each half retried,
+// so only the inputs that really fail end up without a vector. Other
+// errors, such as a bad API key or a server outage, fail the whole call.
+// It is safe for concurrent use.
+type Batcher struct {
+	Embedder  Embedder
+	MaxItems  int // defaults to DefaultBatchItems
+	MaxTokens int // defaults to DefaultBatchTokens
+
+	requests atomic.Int64
+}
+
+// NewBatcher returns a Batcher with the default limits.
+func NewBatcher(e Embedder) *Batcher {
+	return &Batcher{Embedder: e}
+}
+
+func (b *Batcher) Model() string { return b.Embedder.Model() }
+
+// Requests is the number of embedding requests sent, including retries of
+// halves.
+func (b *Batcher) Requests() int64 { return b.requests.Load() }
+
+// Embed returns one vector per text. If some texts could not be embedded
+// it returns the vectors it has, with nil for the failed texts, and a
+// *BatchError naming them.
+func (b *Batcher) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	failed := make(map[int]error)
+
+	for _, batch := range b.batches(texts) {
+		if err := b.embed(ctx, texts, batch, vectors, failed); err != nil {
+			return vectors, err
+		}
+	}
+
+	if len(failed) > 0 {
+		return vectors, &BatchError{Failed: failed, Total: len(texts)}
+	}
+	return vectors, nil
+}
+
+// embed fills vectors for the inputs at indexes, bisecting when the input
+// is rejected. It returns an error when ctx is done or a request fails for
+// a reason no single input can cause.
+func (b *Batcher) embed(ctx context.Context, texts []string, indexes []int, vectors [][]float32, failed map[int]error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	input := make([]string, len(indexes))
+	for i, idx := range indexes {
+		input[i] = texts[idx]
+	}
+
+	b.requests.Add(1)
+	got, err := b.Embedder.Embed(ctx, input)
+	if err == nil && len(got) != len(input) {
+		err = fmt.Errorf("got %d embeddings for %d inputs", len(got), len(input))
+	}
+	if err == nil {
+		for i, idx := range indexes {
+			vectors[idx] = got[i]
+		}
+		return nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if !inputError(err) {
+		return err
+	}
+
+	if len(indexes) == 1 {
+		failed[indexes[0]] = err
+		return nil
+	}
+	mid := len(indexes) / 2
+	if err := b.embed(ctx, texts, indexes[:mid], vectors, failed); err != nil {
+		return err
+	}
+	return b.embed(ctx, texts, indexes[mid:], vectors, failed)
+}
+
+// inputError reports whether err means the server rejected the input
+// itself, with 400 Bad Request, 413 Content Too Large or a context length
+// error, so that a smaller batch may succeed. Authentication errors, rate
+// limits that outlasted retries and server errors would fail every half.
+func inputError(err error) bool {
+	status := 0
+	var statusErr *StatusError
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &statusErr):
+		status = statusErr.StatusCode
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	if status == http.StatusBadRequest || status == http.StatusRequestEntityTooLarge {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "context length") || strings.Contains(msg, "maximum context") ||
+		strings.Contains(msg, "too many tokens")
+}
+
+// batches groups input indexes in order so that no group exceeds MaxItems
+// inputs or MaxTokens estimated tokens. A single input over the token
+// budget gets a group of its own.
+func (b *Batcher) batches(texts []string) [][]int {
+	maxItems, maxTokens := b.MaxItems, b.MaxTokens
+	if maxItems <= 0 {
+		maxItems = DefaultBatchItems
+	}
+	if maxTokens <= 0 {
+		maxTokens = DefaultBatchTokens
+	}
+
+	var batches [][]int
+	var current []int
+	tokens := 0
+	for i, text := range texts {
+		n := EstimateTokens(text)
+		if len(current) > 0 && (len(current) >= maxItems || tokens+n > maxTokens) {
+			batches = append(batches, current)
+			current, tokens = nil, 0
+		}
+		current = append(current, i)
+		tokens += n
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// EstimateTokens approximates the token count of text as one token per four
+// bytes, which is close for English with OpenAI tokenizers.
+func EstimateTokens(text string) int {
+	return len(text)/4 + 1
+}
+
+// BatchError reports the inputs a Batcher could not embed.
+type BatchError struct {
+	Failed map[int]error // input index → last error
+	Total  int
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Failed))
+	for i := range e.Failed {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	msgs := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		msgs = append(msgs, fmt.Sprintf("input %d: %v", i, e.Failed[i]))
+		if len(msgs) == 3 && len(indexes) > 3 {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(indexes)-3))
+			break
+		}
+	}
+	return fmt.Sprintf("%d of %d inputs not embedded: %s", len(e.Failed), e.Total, strings.Join(msgs, "; "))
+}
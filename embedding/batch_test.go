@@ -0,0 +1,148 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// flaky fails any request that contains a poisoned input with err, or
+// with a 400 Bad Request when err is nil.
+type flaky struct {
+	Fake
+	poison   string
+	err      error
+	requests [][]string
+}
+
+func (f *flaky) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.requests = append(f.requests, texts)
+	for _, t := range texts {
+		if t == f.poison {
+			if f.err != nil {
+				return nil, f.err
+			}
+			return nil, &StatusError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request", Message: "rejected"}
+		}
+	}
+	return f.Fake.Embed(ctx, texts)
+}
+
+func TestBatcherSplitsByItemsAndTokens(t *testing.T) {
+	inner := &flaky{Fake: Fake{Dimensions: 8}}
+	b := &Batcher{Embedder: inner, MaxItems: 3, MaxTokens: 20}
+
+	texts := []string{"a", "b", "c", "d", strings.Repeat("x", 100), "e"}
+	vectors, err := b.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes []int
+	for _, r := range inner.requests {
+		sizes = append(sizes, len(r))
+	}
+	// Three per request, and the long text alone since it is over budget.
+	if want := []int{3, 1, 1, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("request sizes = %v, want %v", sizes, want)
+	}
+
+	want, _ := inner.Fake.Embed(context.Background(), texts)
+	for i := range texts {
+		if !reflect.DeepEqual(vectors[i], want[i]) {
+			t.Errorf("vector %d does not belong to %q", i, texts[i])
+		}
+	}
+}
+
+func TestBatcherRetriesOnlyFailedItems(t *testing.T) {
+	inner := &flaky{Fake: Fake{Dimensions: 8}, poison: "bad"}
+	b := &Batcher{Embedder: inner, MaxItems: 4}
+
+	texts := []string{"one", "two", "bad", "four"}
+	vectors, err := b.Embed(context.Background(), texts)
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("err = %v, want *BatchError", err)
+	}
+	if len(batchErr.Failed) != 1 || batchErr.Failed[2] == nil {
+		t.Errorf("failed = %v, want only input 2", batchErr.Failed)
+	}
+	for i, v := range vectors {
+		if (v == nil) != (i == 2) {
+			t.Errorf("vector %d nil = %v", i, v == nil)
+		}
+	}
+	// Full batch, then halves, then the failing half's halves.
+	if len(inner.requests) != 5 {
+		t.Errorf("made %d requests, want 5: %q", len(inner.requests), inner.requests)
+	}
+}
+
+func TestBatcherReturnsRequestErrors(t *testing.T) {
+	for _, err := range []error{
+		&StatusError{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"},
+		&StatusError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"},
+		&openai.APIError{HTTPStatusCode: http.StatusInternalServerError, Message: "server error"},
+	} {
+		inner := &flaky{Fake: Fake{Dimensions: 8}, poison: "one", err: err}
+		b := &Batcher{Embedder: inner, MaxItems: 2}
+
+		_, got := b.Embed(context.Background(), []string{"one", "two", "three", "four"})
+		if got != err {
+			t.Errorf("%v: Embed error = %v, want it returned as is", err, got)
+		}
+		if len(inner.requests) != 1 {
+			t.Errorf("%v: made %d requests, want 1 without bisecting", err, len(inner.requests))
+		}
+	}
+}
+
+func TestInputError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{StatusCode: http.StatusBadRequest}, true},
+		{&StatusError{StatusCode: http.StatusRequestEntityTooLarge}, true},
+		{fmt.Errorf("embed: %w", &openai.APIError{HTTPStatusCode: http.StatusBadRequest}), true},
+		{&openai.RequestError{HTTPStatusCode: http.StatusRequestEntityTooLarge}, true},
+		{errors.New("This model's maximum context length is 8192 tokens"), true},
+		{&StatusError{StatusCode: http.StatusUnauthorized}, false},
+		{&openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, false},
+		{&StatusError{StatusCode: http.StatusBadGateway}, false},
+		{errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := inputError(tt.err); got != tt.want {
+			t.Errorf("inputError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBatcherConcurrentUse(t *testing.T) {
+	// Run with -race: serve shares one Batcher between requests.
+	b := NewBatcher(NewFake(8))
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := One(context.Background(), b, fmt.Sprint("query ", i)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := b.Requests(); got != 16 {
+		t.Errorf("Requests() = %d, want 16", got)
+	}
+}
@@ -0,0 +1,47 @@
+// Package embedding turns episode text into vectors.
+package embedding
+
+import (
+	"context"
+	"fmt"
+)
+
+// Embedder generates one vector per input text, in input order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Model names the model behind the vectors. Vectors from different
+	// models must not be compared.
+	Model() string
+}
+
+// One embeds a single text.
+func One(ctx context.Context, e Embedder, text string) ([]float32, error) {
+	vectors, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("got %d embeddings for 1 input", len(vectors))
+	}
+	return vectors[0], nil
+}
+
+// byIndex places vectors returned with an index into input order and checks
+// that every input got one.
+func byIndex(n int, index func(i int) int, vector func(i int) []float32, count int) ([][]float32, error) {
+	vectors := make([][]float32, n)
+	for i := 0; i < count; i++ {
+		idx := index(i)
+		if idx < 0 || idx >= n {
+			return nil, fmt.Errorf("embedding index %d out of range for %d inputs", idx, n)
+		}
+		vectors[idx] = vector(i)
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("no embedding returned for input %d", i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,65 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestFakeIsDeterministic(t *testing.T) {
+	f := NewFake(32)
+	a, err := f.Embed(context.Background(), []string{"Jake Longstreth", "Jonah Hill"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := f.Embed(context.Background(), []string{"Jake Longstreth", "Jonah Hill"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Error("same input produced different vectors")
+	}
+	if reflect.DeepEqual(a[0], a[1]) {
+		t.Error("different inputs produced the same vector")
+	}
+	if len(a[0]) != 32 {
+		t.Errorf("got %d dimensions, want 32", len(a[0]))
+	}
+}
+
+func TestHTTPOrdersByIndex(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "nomic-embed-text" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		// Answer out of order to check vectors are matched by index.
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"index": 1, "embedding": []float32{2}},
+				{"index": 0, "embedding": []float32{1}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	e := NewHTTP(srv.URL+"/v1/", "nomic-embed-text", "")
+	got, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{1}, {2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Embed = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Fake is a deterministic Embedder for tests and offline runs. Each word is
+// hashed into one of Dimensions buckets, so texts that share words get
+// similar vectors without calling any API.
+type Fake struct {
+	Dimensions int
+}
+
+// NewFake returns a Fake producing vectors of the given size.
+func NewFake(dimensions int) *Fake {
+	if dimensions <= 0 {
+		dimensions = 64
+	}
+	return &Fake{Dimensions: dimensions}
+}
+
+func (f *Fake) Model() string { return fmt.Sprintf("fake-%d", f.Dimensions) }
+
+func (f *Fake) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = f.vector(text)
+	}
+	return vectors, nil
+}
+
+func (f *Fake) vector(text string) []float32 {
+	v := make([]float32, f.Dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		h := fnv.New64a()
+		h.Write([]byte(w))
+		sum := h.Sum64()
+		sign := float32(1)
+		if sum&(1<<63) != 0 {
+			sign = -1
+		}
+		v[sum%uint64(f.Dimensions)] += sign
+	}
+
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		v[0] = 1
+		return v
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+	return v
+}
@@ -0,0 +1,94 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// HTTP embeds texts with any server that speaks the OpenAI embeddings
+// protocol, such as Ollama or llama.cpp running locally.
+type HTTP struct {
+	// BaseURL is the API root, e.g. "http://localhost:11434/v1".
+	BaseURL string
+
+	// APIKey is sent as a bearer token when set.
+	APIKey string
+
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+
+	model string
+}
+
+// NewHTTP returns an Embedder that posts to baseURL + "/embeddings".
+func NewHTTP(baseURL, model, apiKey string) *HTTP {
+	return &HTTP{BaseURL: strings.TrimRight(baseURL, "/"), APIKey: apiKey, model: model}
+}
+
+func (h *HTTP) Model() string { return h.model }
+
+func (h *HTTP) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}{h.model, texts})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.BaseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.APIKey)
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, &StatusError{StatusCode: res.StatusCode, Status: res.Status, Message: strings.TrimSpace(string(msg))}
+	}
+
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode embeddings: %w", err)
+	}
+
+	return byIndex(len(texts),
+		func(i int) int { return out.Data[i].Index },
+		func(i int) []float32 { return out.Data[i].Embedding },
+		len(out.Data))
+}
+
+// StatusError is returned by HTTP when the server answers with a status
+// other than 200 OK.
+type StatusError struct {
+	StatusCode int
+	Status     string // e.g. "400 Bad Request"
+	Message    string // start of the response body
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("embeddings: %s: %s", e.Status, e.Message)
+}
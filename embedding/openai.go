@@ -0,0 +1,54 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// DefaultOpenAIModel is the model every stored embedding was generated with
+// before the model became configurable.
+const DefaultOpenAIModel = string(openai.AdaEmbeddingV2)
+
+// OpenAI embeds texts with the OpenAI embeddings API.
+type OpenAI struct {
+	client     *openai.Client
+	model      string
+	dimensions int
+}
+
+// NewOpenAI returns an Embedder for model. dimensions shortens the vectors
+// for models that support it (text-embedding-3 and later); 0 keeps the
+// model's default size.
+func NewOpenAI(client *openai.Client, model string, dimensions int) *OpenAI {
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	return &OpenAI{client: client, model: model, dimensions: dimensions}
+}
+
+// Model returns the model name, suffixed with "/<dimensions>" when the
+// vectors are shortened, since those are not comparable with full ones.
+func (o *OpenAI) Model() string {
+	if o.dimensions > 0 {
+		return fmt.Sprintf("%s/%d", o.model, o.dimensions)
+	}
+	return o.model
+}
+
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := o.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Model:      openai.EmbeddingModel(o.model),
+		Input:      texts,
+		Dimensions: o.dimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return byIndex(len(texts),
+		func(i int) int { return resp.Data[i].Index },
+		func(i int) []float32 { return resp.Data[i].Embedding },
+		len(resp.Data))
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"webscraper/store"
+)
+
+// embeddingText combines the fields we embed into a single text input. An
+// episode whose date could not be parsed is embedded with the raw date.
+func embeddingText(episode store.Episode) string {
+	date := episode.FormattedDate
+	if date == "" {
+		date = episode.Date
+	}
+	return fmt.Sprintf(
+		"Title: %s. Guests: %s. Date: %s. Notes: %s",
+		episode.Title,
+		strings.Join(episode.Guests, ", "), // Convert guest slice to a string
+		date,
+		episode.Notes,
+	)
+}
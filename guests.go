@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"webscraper/scraper"
+	"webscraper/store"
+)
+
+func runGuests(ctx context.Context, args []string) error {
+	fs := newFlagSet("guests", "[name]", `
+Print guest leaderboards from the guest records rebuilt on every sync:
+the guests with the most appearances and the pairs of guests who appeared
+together most often. With a name, print that guest's appearance history
+and frequent co-guests instead.`)
+	storeCfg := addStoreFlags(fs)
+	top := fs.Int("top", 10, "number of entries per leaderboard")
+	rebuild := fs.Bool("rebuild", false, "rebuild the guest records from the stored episodes first")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name := strings.TrimSpace(strings.Join(fs.Args(), " "))
+
+	episodeStore, err := storeCfg.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer episodeStore.Close(context.Background())
+
+	if *rebuild {
+		n, err := rebuildGuests(ctx, episodeStore)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Rebuilt %d guest records.\n", n)
+	}
+
+	guests, err := episodeStore.Guests(ctx)
+	if err != nil {
+		return err
+	}
+	if len(guests) == 0 {
+		fmt.Println(`No guest records yet; run "tc scrape" or "tc guests -rebuild".`)
+		return nil
+	}
+
+	if name != "" {
+		return printGuest(guests, name)
+	}
+	printLeaderboards(guests, *top)
+	return nil
+}
+
+// rebuildGuests derives the guest records from every stored episode and
+// replaces the stored ones. It returns the number of guests.
+func rebuildGuests(ctx context.Context, s store.EpisodeStore) (int, error) {
+	var episodes []store.Episode
+	err := store.ForEach(ctx, s, func(e store.Episode) error {
+		e.Embedding = nil
+		episodes = append(episodes, e)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	guests := store.BuildGuests(episodes)
+	return len(guests), s.ReplaceGuests(ctx, guests)
+}
+
+func printLeaderboards(guests []store.Guest, top int) {
+	fmt.Println("Most appearances:")
+	for i, g := range guests {
+		if i == top {
+			break
+		}
+		fmt.Printf("%3d. %-30s %3d  (%s – %s)\n", i+1, g.Name, g.Appearances, appearanceDate(g.First), appearanceDate(g.Last))
+	}
+
+	pairs := guestPairs(guests)
+	if len(pairs) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Most frequent pairings:")
+	for i, p := range pairs {
+		if i == top {
+			break
+		}
+		fmt.Printf("%3d. %-45s %3d\n", i+1, p.a+" & "+p.b, p.episodes)
+	}
+}
+
+// guestPair counts the episodes two guests appeared in together.
+type guestPair struct {
+	a, b     string // names, a's ID before b's
+	episodes int
+}
+
+// guestPairs counts every pair of guests that shared an episode, most
+// frequent first. Pairs come from each guest's episode IDs rather than the
+// CoGuests lists, which are capped at store.MaxCoGuests per guest.
+func guestPairs(guests []store.Guest) []guestPair {
+	byEpisode := make(map[string][]*store.Guest)
+	for i := range guests {
+		g := &guests[i]
+		for _, id := range g.EpisodeIDs {
+			byEpisode[id] = append(byEpisode[id], g)
+		}
+	}
+
+	type key struct{ a, b string }
+	counts := make(map[key]*guestPair)
+	for _, together := range byEpisode {
+		for i, g := range together {
+			for _, co := range together[i+1:] {
+				a, b := g, co
+				if b.ID < a.ID {
+					a, b = b, a
+				}
+				p := counts[key{a.ID, b.ID}]
+				if p == nil {
+					p = &guestPair{a: a.Name, b: b.Name}
+					counts[key{a.ID, b.ID}] = p
+				}
+				p.episodes++
+			}
+		}
+	}
+
+	pairs := make([]guestPair, 0, len(counts))
+	for _, p := range counts {
+		pairs = append(pairs, *p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].episodes != pairs[j].episodes {
+			return pairs[i].episodes > pairs[j].episodes
+		}
+		return pairs[i].a+pairs[i].b < pairs[j].a+pairs[j].b
+	})
+	return pairs
+}
+
+func printGuest(guests []store.Guest, name string) error {
+	id := scraper.GuestID(name)
+	var g *store.Guest
+	for i := range guests {
+		if guests[i].ID == id {
+			g = &guests[i]
+			break
+		}
+	}
+	if g == nil {
+		return fmt.Errorf("no guest named %q", name)
+	}
+
+	fmt.Printf("%s (%s)\n", g.Name, g.ID)
+	if g.URL != "" {
+		fmt.Printf("  %s\n", g.URL)
+	}
+	fmt.Printf("  %d appearances\n", g.Appearances)
+	fmt.Printf("  First: #%s %s (%s)\n", g.First.EpisodeNo, g.First.Title, appearanceDate(g.First))
+	fmt.Printf("  Last:  #%s %s (%s)\n", g.Last.EpisodeNo, g.Last.Title, appearanceDate(g.Last))
+	if len(g.CoGuests) > 0 {
+		fmt.Println("  Most often with:")
+		for _, co := range g.CoGuests {
+			fmt.Printf("    %-30s %3d\n", co.Name, co.Episodes)
+		}
+	}
+	return nil
+}
+
+func appearanceDate(a store.Appearance) string {
+	if a.Timestamp.IsZero() {
+		if a.Date != "" {
+			return a.Date
+		}
+		return "undated"
+	}
+	return a.Timestamp.Format("2006-01-02")
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"webscraper/scraper"
+	"webscraper/store"
+)
+
+func TestGuestPairsAreNotCappedByCoGuests(t *testing.T) {
+	// One episode with more guests than a record keeps co-guests for,
+	// and a second one two of them share.
+	var crowd []string
+	for i := 0; i < store.MaxCoGuests+2; i++ {
+		crowd = append(crowd, fmt.Sprintf("Guest %02d", i))
+	}
+	guests := store.BuildGuests([]store.Episode{
+		{Episode: scraper.Episode{ID: "1", EpisodeNo: "1", Guests: crowd}},
+		{Episode: scraper.Episode{ID: "2", EpisodeNo: "2", Guests: []string{"Guest 00", "Guest 11"}}},
+	})
+
+	pairs := guestPairs(guests)
+	n := len(crowd)
+	if want := n * (n - 1) / 2; len(pairs) != want {
+		t.Errorf("got %d pairs, want %d", len(pairs), want)
+	}
+	if len(pairs) > 0 && (pairs[0] != guestPair{a: "Guest 00", b: "Guest 11", episodes: 2}) {
+		t.Errorf("top pair = %+v, want Guest 00 & Guest 11 with 2 episodes", pairs[0])
+	}
+	for _, p := range pairs[1:] {
+		if p.episodes != 1 {
+			t.Errorf("pair %+v, want 1 episode", p)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"webscraper/scraper"
+)
+
+func runMigrateIDs(ctx context.Context, args []string) error {
+	fs := newFlagSet("migrate-ids", "", `
+Re-key stored episodes whose _id was derived from the episode URL and
+title, as older versions did. Each document is matched to the scraped guide
+by episode number and title, then copied to its new _id with the current
+url, keeping its embedding and every other field, and the old document is
+deleted.
+
+Run once after upgrading. Running it again is a no-op.`)
+	mongoCfg := addMongoFlags(fs)
+	guideURL := fs.String("url", scraper.EpisodeGuideURL, "Episode Guide URL")
+	fromDir := addFromDirFlag(fs)
+	retryPolicy := addRetryFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "print what would change without writing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, collection, err := mongoCfg.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(context.Background())
+
+	scraped, err := scraper.ScrapeEpisodeGuide(ctx, scraper.Options{
+		URL:       *guideURL,
+		Transport: wikiTransport(*fromDir, retryPolicy),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Index the guide by episode number and title, which did not change.
+	byKey := make(map[string]scraper.Episode, len(scraped))
+	ambiguous := make(map[string]bool)
+	for _, e := range scraped {
+		key := e.EpisodeNo + "\x00" + e.Title
+		if _, ok := byKey[key]; ok {
+			ambiguous[key] = true
+		}
+		byKey[key] = e
+	}
+
+	// Load everything up front so the cursor never sees our own inserts.
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+
+	var migrated, merged, unchanged, unmatched int
+	for _, doc := range docs {
+		oldID := doc["_id"]
+		episodeNo, _ := doc["episode_no"].(string)
+		title, _ := doc["title"].(string)
+
+		key := episodeNo + "\x00" + title
+		e, ok := byKey[key]
+		if !ok || ambiguous[key] {
+			fmt.Printf("⚠️ No unique guide match for %v (#%s %s), leaving it\n", oldID, episodeNo, title)
+			unmatched++
+			continue
+		}
+		if oldID == e.ID {
+			unchanged++
+			continue
+		}
+
+		fmt.Printf("🔁 %v → %s (#%s %s)\n", oldID, e.ID, episodeNo, title)
+		if *dryRun {
+			migrated++
+			continue
+		}
+
+		// A scrape may already have created the new document; keep it and
+		// carry the old embedding over if it has none.
+		var existing bson.M
+		err := collection.FindOne(ctx, bson.M{"_id": e.ID}).Decode(&existing)
+		switch {
+		case err == nil:
+			if _, has := existing["embedding"]; !has && doc["embedding"] != nil {
+				update := bson.M{"$set": bson.M{"embedding": doc["embedding"]}}
+				if _, err := collection.UpdateOne(ctx, bson.M{"_id": e.ID}, update); err != nil {
+					return err
+				}
+			}
+			merged++
+		case errors.Is(err, mongo.ErrNoDocuments):
+			doc["_id"] = e.ID
+			doc["url"] = e.Url
+			if _, err := collection.InsertOne(ctx, doc); err != nil {
+				return err
+			}
+			migrated++
+		default:
+			return err
+		}
+
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": oldID}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✅ Migrated %d, merged %d, already current %d, unmatched %d.\n", migrated, merged, unchanged, unmatched)
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"webscraper/store"
+)
+
+// mongoConfig holds the connection settings shared by every subcommand.
+// Values default to the MONGO_* environment variables.
+type mongoConfig struct {
+	uri         string
+	db          string
+	collection  string
+	vectorIndex string
+}
+
+func addMongoFlags(fs *flag.FlagSet) *mongoConfig {
+	cfg := &mongoConfig{}
+	fs.StringVar(&cfg.uri, "mongo-uri", os.Getenv("MONGO_URI"), "MongoDB connection string (env MONGO_URI)")
+	fs.StringVar(&cfg.db, "db", os.Getenv("MONGO_DB_NAME"), "database name (env MONGO_DB_NAME)")
+	fs.StringVar(&cfg.collection, "collection", os.Getenv("MONGO_COLLECTION"), "episode collection (env MONGO_COLLECTION)")
+	fs.StringVar(&cfg.vectorIndex, "vector-index", envOr("MONGO_VECTOR_INDEX", "vector_index"), `Atlas Vector Search index on "embedding"; empty always ranks in process (env MONGO_VECTOR_INDEX)`)
+	return cfg
+}
+
+// connect opens and pings a MongoDB client and returns a handle for the
+// episode collection. Callers must Disconnect the client when done.
+func (cfg *mongoConfig) connect(ctx context.Context) (*mongo.Client, *mongo.Collection, error) {
+	if cfg.uri == "" {
+		return nil, nil, fmt.Errorf("MongoDB URI not set (use -mongo-uri or MONGO_URI)")
+	}
+	if cfg.db == "" || cfg.collection == "" {
+		return nil, nil, fmt.Errorf("database and collection must be set (MONGO_DB_NAME, MONGO_COLLECTION)")
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.uri))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Check the connection
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(ctx)
+		return nil, nil, err
+	}
+	fmt.Println("✅ Connected to MongoDB!")
+
+	return client, client.Database(cfg.db).Collection(cfg.collection), nil
+}
+
+// storeConfig selects where episodes are stored: MongoDB by default,
+// PostgreSQL with pgvector, or a local SQLite file.
+type storeConfig struct {
+	kind        string
+	sqlitePath  string
+	postgresURL string
+	mongo       *mongoConfig
+}
+
+func addStoreFlags(fs *flag.FlagSet) *storeConfig {
+	cfg := &storeConfig{mongo: addMongoFlags(fs)}
+	fs.StringVar(&cfg.kind, "store", envOr("TC_STORE", "mongo"), "episode store: mongo, postgres or sqlite (env TC_STORE)")
+	fs.StringVar(&cfg.postgresURL, "postgres-url", os.Getenv("DATABASE_URL"), "PostgreSQL connection string for -store postgres (env DATABASE_URL)")
+	fs.StringVar(&cfg.sqlitePath, "sqlite-path", envOr("SQLITE_PATH", "tc.db"), "SQLite database file for -store sqlite (env SQLITE_PATH)")
+	return cfg
+}
+
+// open connects to the configured store. Callers must Close it when done.
+func (cfg *storeConfig) open(ctx context.Context) (store.EpisodeStore, error) {
+	switch cfg.kind {
+	case "mongo":
+		_, collection, err := cfg.mongo.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s := store.NewMongo(collection)
+		s.VectorIndex = cfg.mongo.vectorIndex
+		return s, nil
+	case "postgres":
+		if cfg.postgresURL == "" {
+			return nil, fmt.Errorf("PostgreSQL URL not set (use -postgres-url or DATABASE_URL)")
+		}
+		s, err := store.OpenPostgres(ctx, cfg.postgresURL)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println("✅ Connected to PostgreSQL!")
+		return s, nil
+	case "sqlite":
+		s, err := store.OpenSQLite(ctx, cfg.sqlitePath)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("✅ Opened SQLite database %s\n", cfg.sqlitePath)
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown store %q (want mongo, postgres or sqlite)", cfg.kind)
+	}
+}
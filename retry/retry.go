@@ -0,0 +1,189 @@
+// Package retry retries HTTP requests that fail with rate limits, server
+// errors or network errors, using exponential backoff with jitter.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults used when a Policy field is zero.
+const (
+	DefaultMaxAttempts   = 5
+	DefaultBaseDelay     = 500 * time.Millisecond
+	DefaultMaxDelay      = 30 * time.Second
+	DefaultMaxRetryAfter = 2 * time.Minute
+)
+
+// Policy decides how often and how long to wait before retrying.
+type Policy struct {
+	// MaxAttempts is the total number of tries, including the first.
+	MaxAttempts int
+
+	// BaseDelay is the backoff before the first retry. It doubles on every
+	// retry up to MaxDelay, and the actual wait is a random duration up to
+	// that bound ("full jitter").
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// MaxRetryAfter caps how long a server's Retry-After header can make us
+	// wait.
+	MaxRetryAfter time.Duration
+
+	// Stats, when set, counts retries and give-ups.
+	Stats *Stats
+}
+
+// Stats counts what a Policy did. It is safe for concurrent use.
+type Stats struct {
+	retries atomic.Int64
+	giveUps atomic.Int64
+}
+
+// Retries is the number of requests that were sent again.
+func (s *Stats) Retries() int64 { return s.retries.Load() }
+
+// GiveUps is the number of requests that still failed after the last
+// attempt.
+func (s *Stats) GiveUps() int64 { return s.giveUps.Load() }
+
+func (s *Stats) String() string {
+	return fmt.Sprintf("%d retries, %d gave up", s.Retries(), s.GiveUps())
+}
+
+// Transport returns an http.RoundTripper that sends requests through base
+// (http.DefaultTransport when nil) and retries them under p.
+func (p *Policy) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{policy: p, base: base}
+}
+
+type transport struct {
+	policy *Policy
+	base   http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.policy
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultMaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return res, err
+		}
+		if !shouldRetry(res, err) {
+			return res, err
+		}
+		if attempt >= attempts || (req.Body != nil && req.GetBody == nil) {
+			p.count(func(s *Stats) { s.giveUps.Add(1) })
+			return res, err
+		}
+
+		wait := p.backoff(attempt)
+		if res != nil {
+			if after, ok := retryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+				wait = min(after, p.maxRetryAfter())
+			}
+			// Drain so the connection can be reused.
+			io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+			res.Body.Close()
+		}
+
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		p.count(func(s *Stats) { s.retries.Add(1) })
+	}
+}
+
+// shouldRetry reports whether a response or error is worth trying again.
+func shouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusRequestTimeout,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns a random wait up to BaseDelay·2^(attempt-1), capped at
+// MaxDelay.
+func (p *Policy) backoff(attempt int) time.Duration {
+	base, maxDelay := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxDelay
+	}
+	bound := maxDelay
+	if shift := attempt - 1; shift < 32 && base<<shift < maxDelay {
+		bound = base << shift
+	}
+	return time.Duration(rand.Int64N(int64(bound) + 1))
+}
+
+func (p *Policy) maxRetryAfter() time.Duration {
+	if p.MaxRetryAfter > 0 {
+		return p.MaxRetryAfter
+	}
+	return DefaultMaxRetryAfter
+}
+
+func (p *Policy) count(fn func(*Stats)) {
+	if p.Stats != nil {
+		fn(p.Stats)
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package retry
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransportRetriesRateLimits(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if len(bodies) < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	stats := &Stats{}
+	p := &Policy{BaseDelay: time.Millisecond, Stats: stats}
+	client := &http.Client{Transport: p.Transport(nil)}
+
+	res, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", res.StatusCode)
+	}
+	if stats.Retries() != 2 || stats.GiveUps() != 0 {
+		t.Errorf("stats = %s, want 2 retries, 0 gave up", stats)
+	}
+	for i, b := range bodies {
+		if b != "payload" {
+			t.Errorf("attempt %d body = %q, want the original body", i+1, b)
+		}
+	}
+}
+
+func TestTransportGivesUp(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	stats := &Stats{}
+	p := &Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, Stats: stats}
+	client := &http.Client{Transport: p.Transport(nil)}
+
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusServiceUnavailable || calls != 3 {
+		t.Errorf("status %d after %d calls, want 503 after 3", res.StatusCode, calls)
+	}
+	if stats.Retries() != 2 || stats.GiveUps() != 1 {
+		t.Errorf("stats = %s, want 2 retries, 1 gave up", stats)
+	}
+}
+
+func TestTransportDoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	p := &Policy{BaseDelay: time.Millisecond}
+	res, err := (&http.Client{Transport: p.Transport(nil)}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if calls != 1 {
+		t.Errorf("made %d calls for a 404, want 1", calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"7", 7 * time.Second, true},
+		{"Mon, 01 Jan 2024 00:00:30 GMT", 30 * time.Second, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"webscraper/retry"
+	"webscraper/scraper"
+)
+
+func runScrape(ctx context.Context, args []string) error {
+	fs := newFlagSet("scrape", "", `
+Scrape the Episode Guide and each episode's page and sync the store with
+a single batch of upserts keyed on the episode ID. Only episodes whose
+scraped content changed are written, and the embedding API is only called
+for episodes whose embedding text or model changed. Episodes that
+disappeared from the wiki are reported, and deleted with -prune. The
+guest records are rebuilt from the synced episodes afterwards.
+
+Every guide table's header row is checked against the expected Episode,
+Title, Date, Guests, Top 5 Comparison Year and Notes columns. When one
+differs the scrape stops before writing anything and prints the
+differences; with -strict-schema=false they are printed as warnings and
+the columns that can still be found are used. Renamed headers that are
+still recognized are only printed as warnings.
+
+With -from-dir the pages are read from a directory saved by "tc snapshot"
+instead of the network. With -dry-run the episodes are printed as JSON and
+nothing is written.`)
+	storeCfg := addStoreFlags(fs)
+	guideURL := fs.String("url", scraper.EpisodeGuideURL, "Episode Guide URL")
+	fromDir := addFromDirFlag(fs)
+	aliasesPath := fs.String("guest-aliases", os.Getenv("GUEST_ALIASES"), `JSON file mapping alternative guest names to canonical ones, e.g. {"Jake": "Jake Longstreth"} (env GUEST_ALIASES)`)
+	embedderCfg := addEmbedderFlags(fs)
+	retryPolicy := addRetryFlags(fs)
+	prune := fs.Bool("prune", false, "delete stored episodes that are no longer on the wiki")
+	strictSchema := fs.Bool("strict-schema", true, "fail before writing anything when a guide table's columns changed")
+	pages := fs.Bool("pages", true, "also crawl each episode's page for guests, topics and music")
+	dryRun := fs.Bool("dry-run", false, "print the scraped episodes as JSON instead of syncing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var aliases scraper.Aliases
+	if *aliasesPath != "" {
+		var err error
+		if aliases, err = scraper.LoadAliases(*aliasesPath); err != nil {
+			return err
+		}
+	}
+
+	transport := wikiTransport(*fromDir, retryPolicy)
+	scraped, err := scraper.ScrapeEpisodeGuide(ctx, scraper.Options{
+		URL:          *guideURL,
+		Transport:    transport,
+		Aliases:      aliases,
+		StrictSchema: *strictSchema,
+		Warn: func(err error) {
+			fmt.Fprintf(os.Stderr, "⚠️ %v\n", err)
+		},
+	})
+	var drift *scraper.SchemaError
+	if errors.As(err, &drift) {
+		return fmt.Errorf("the Episode Guide layout changed; nothing was written (use -strict-schema=false to scrape anyway):\n%w", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *pages {
+		err := scraper.CrawlEpisodePages(ctx, scraped, scraper.Options{
+			Transport: transport,
+			Warn: func(err error) {
+				fmt.Fprintf(os.Stderr, "⚠️ Skipping page: %v\n", err)
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if *dryRun {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(scraped)
+	}
+
+	episodes, err := storeCfg.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer episodes.Close(context.Background())
+
+	embedder, err := embedderCfg.new(retryPolicy)
+	if err != nil {
+		return err
+	}
+
+	report, err := syncEpisodes(ctx, episodes, scraped, syncOptions{
+		embedder: embedder,
+		prune:    *prune,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Sync complete: %s (%d embedding requests; HTTP %s).\n", report, embedder.Requests(), retryPolicy.Stats)
+
+	n, err := rebuildGuests(ctx, episodes)
+	if err != nil {
+		return fmt.Errorf("rebuild guests: %w", err)
+	}
+	fmt.Printf("✅ Rebuilt %d guest records.\n", n)
+	return nil
+}
+
+func runSnapshot(ctx context.Context, args []string) error {
+	fs := newFlagSet("snapshot", "", `
+Save the Episode Guide and every episode page it links to as HTML files,
+so a run can be reproduced offline with "tc scrape -from-dir <dir>".`)
+	out := fs.String("out", "snapshot", "directory to write the pages to")
+	guideURL := fs.String("url", scraper.EpisodeGuideURL, "Episode Guide URL")
+	retryPolicy := addRetryFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	written, err := scraper.SaveSnapshot(ctx, *out, scraper.Options{
+		URL:       *guideURL,
+		Transport: retryPolicy.Transport(nil),
+		Warn: func(err error) {
+			fmt.Printf("⚠️ Skipping page: %v\n", err)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Saved %d pages to %s (HTTP %s).\n", written, *out, retryPolicy.Stats)
+	return nil
+}
+
+// addFromDirFlag registers -from-dir, which reads wiki pages from a
+// snapshot directory instead of the network.
+func addFromDirFlag(fs *flag.FlagSet) *string {
+	return fs.String("from-dir", "", `read wiki pages from a directory saved by "tc snapshot" instead of the network`)
+}
+
+// wikiTransport returns the transport for wiki requests: the snapshot in
+// dir when set, otherwise the network under the retry policy.
+func wikiTransport(dir string, policy *retry.Policy) http.RoundTripper {
+	if dir != "" {
+		return scraper.DirTransport(dir)
+	}
+	return policy.Transport(nil)
+}
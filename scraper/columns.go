@@ -0,0 +1,235 @@
+package scraper
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Columns of the Episode Guide tables.
+const (
+	columnEpisode = "episode"
+	columnTitle   = "title"
+	columnDate    = "date"
+	columnGuests  = "guests"
+	columnTop5    = "top_5_comparison_year"
+	columnNotes   = "notes"
+)
+
+// guideColumns maps a column to the index of its cell in a row.
+type guideColumns map[string]int
+
+// expectedColumns are the columns every guide table should have, in their
+// usual order, with the header the wiki uses for each.
+var expectedColumns = []struct{ column, header string }{
+	{columnEpisode, "Episode"},
+	{columnTitle, "Title"},
+	{columnDate, "Date"},
+	{columnGuests, "Guests"},
+	{columnTop5, "Top 5 Comparison Year"},
+	{columnNotes, "Notes"},
+}
+
+// defaultColumns is the layout of a table without a header row.
+var defaultColumns = guideColumns{
+	columnEpisode: 0,
+	columnTitle:   1,
+	columnDate:    2,
+	columnGuests:  3,
+	columnTop5:    4,
+	columnNotes:   5,
+}
+
+// columnName maps a header cell of the guide to the column it holds, or ""
+// for a column the parser doesn't use. Cases are checked in order so that
+// "Episode Title" is the title and "Top 5 Comparison Year" is not a date.
+func columnName(header string) string {
+	h := strings.ToLower(header)
+	h = strings.Join(strings.Fields(strings.NewReplacer(".", " ", ":", " ").Replace(h)), " ")
+	switch {
+	case strings.Contains(h, "top 5"), strings.Contains(h, "comparison"):
+		return columnTop5
+	case strings.Contains(h, "guest"), h == "featuring":
+		return columnGuests
+	case strings.Contains(h, "date"), h == "aired":
+		return columnDate
+	case strings.Contains(h, "title"), h == "name":
+		return columnTitle
+	case strings.Contains(h, "note"), strings.Contains(h, "comment"):
+		return columnNotes
+	case strings.Contains(h, "episode"), h == "ep", h == "#", h == "no", h == "number":
+		return columnEpisode
+	}
+	return ""
+}
+
+// guideHeader is a parsed header row.
+type guideHeader struct {
+	cols       guideColumns
+	unexpected []string       // headers that could not be mapped, including repeats
+	renamed    []HeaderRename // mapped headers that differ from the usual text
+}
+
+// parseHeader maps the header cells of row to columns. It reports false
+// when row has data cells, i.e. is not a header row.
+func parseHeader(row *goquery.Selection) (h guideHeader, ok bool) {
+	cells := row.Children()
+	if cells.Length() == 0 || cells.Filter("td").Length() > 0 {
+		return h, false
+	}
+	h.cols = make(guideColumns)
+	cells.Each(func(i int, cell *goquery.Selection) {
+		header := cleanText(cell)
+		name := columnName(header)
+		if _, seen := h.cols[name]; name == "" || seen {
+			h.unexpected = append(h.unexpected, header)
+			return
+		}
+		h.cols[name] = i
+		if want := expectedHeader(name); headerKey(header) != headerKey(want) {
+			h.renamed = append(h.renamed, HeaderRename{Expected: want, Found: header})
+		}
+	})
+	return h, true
+}
+
+// headerKey is header without case, spacing or punctuation, so that
+// "Guest(s)" is still "Guests".
+func headerKey(header string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, header)
+}
+
+func expectedHeader(column string) string {
+	for _, c := range expectedColumns {
+		if c.column == column {
+			return c.header
+		}
+	}
+	return ""
+}
+
+// SchemaError reports a guide table whose header row differs from the
+// expected Episode, Title, Date, Guests, Top 5 Comparison Year and Notes
+// columns, which usually means the wiki's editors changed the layout.
+type SchemaError struct {
+	Table   int    // position of the table on the page, from 1
+	Heading string // heading before the table, see Episode.Season
+
+	// NoHeader is set when the table has no header row and was read in
+	// the usual column order.
+	NoHeader bool
+
+	Missing    []string // expected headers that were not found
+	Unexpected []string // headers the parser doesn't use
+
+	// Renamed lists headers that were matched to an expected column by
+	// name alone, such as "Air date" for "Date". The column is still read,
+	// so renames on their own don't fail a strict scrape, but they are
+	// reported in case the column now means something else.
+	Renamed []HeaderRename
+}
+
+// HeaderRename is a guide header that differs from the expected one but
+// was mapped to its column.
+type HeaderRename struct {
+	Expected string // e.g. "Guests"
+	Found    string // e.g. "Featuring"
+}
+
+// renamedOnly reports whether the only differences are renamed headers.
+func (e *SchemaError) renamedOnly() bool {
+	return !e.NoHeader && len(e.Missing) == 0 && len(e.Unexpected) == 0
+}
+
+func (e *SchemaError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "table %d", e.Table)
+	if e.Heading != "" {
+		fmt.Fprintf(&b, " (%q)", e.Heading)
+	}
+	if e.NoHeader {
+		b.WriteString(": no header row; assumed " + expectedHeaders())
+		return b.String()
+	}
+	b.WriteString(": header row changed")
+	for _, h := range e.Missing {
+		b.WriteString("\n\t- " + h + " (missing)")
+	}
+	for _, h := range e.Unexpected {
+		b.WriteString("\n\t+ " + h + " (new)")
+	}
+	for _, r := range e.Renamed {
+		b.WriteString("\n\t~ " + r.Found + " (read as " + r.Expected + ")")
+	}
+	return b.String()
+}
+
+func expectedHeaders() string {
+	headers := make([]string, len(expectedColumns))
+	for i, c := range expectedColumns {
+		headers[i] = c.header
+	}
+	return strings.Join(headers, ", ")
+}
+
+// checkSchema compares a table's header with the expected columns. It
+// returns nil when they match. header is nil for a table without a header
+// row.
+func checkSchema(table int, heading string, header *guideHeader) *SchemaError {
+	if header == nil {
+		return &SchemaError{Table: table, Heading: heading, NoHeader: true}
+	}
+	var missing []string
+	for _, c := range expectedColumns {
+		if _, ok := header.cols[c.column]; !ok {
+			missing = append(missing, c.header)
+		}
+	}
+	if len(missing) == 0 && len(header.unexpected) == 0 && len(header.renamed) == 0 {
+		return nil
+	}
+	return &SchemaError{Table: table, Heading: heading, Missing: missing, Unexpected: header.unexpected, Renamed: header.renamed}
+}
+
+// cell returns the cell of row in column, or an empty selection when the
+// table has no such column or the row is too short.
+func (c guideColumns) cell(row *goquery.Selection, column string) *goquery.Selection {
+	i, ok := c[column]
+	cells := row.Children()
+	if !ok || i >= cells.Length() {
+		return cells.Slice(0, 0)
+	}
+	return cells.Eq(i)
+}
+
+// width is the number of cells a row needs to hold every mapped column.
+func (c guideColumns) width() int {
+	w := 0
+	for _, i := range c {
+		w = max(w, i+1)
+	}
+	return w
+}
+
+func (c guideColumns) text(row *goquery.Selection, column string) string {
+	return strings.TrimSpace(c.cell(row, column).Text())
+}
+
+// tableHeading returns the text of the closest h2, h3 or h4 before table,
+// which names the year or show era the table covers, or "" when there is
+// none. Headings before an enclosing element count too.
+func tableHeading(table *goquery.Selection) string {
+	for s := table; s.Length() > 0 && !s.Is("body"); s = s.Parent() {
+		if h := s.PrevAllFiltered("h2, h3, h4").First(); h.Length() > 0 {
+			return headingText(h)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,182 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date precisions: how much of a guide date was known.
+const (
+	PrecisionDay   = "day"
+	PrecisionMonth = "month"
+	PrecisionYear  = "year"
+)
+
+// Date statuses: whether the guide date could be parsed. An episode whose
+// date is DateUnknown has no timestamp or formatted date, only the raw Date.
+const (
+	DateOK      = "ok"
+	DateUnknown = "unknown"
+)
+
+// GuideDate is a parsed guide date. Time is the first day the date covers:
+// "May 2015" is May 1, 2015 and a range such as "May 3–4, 2015" starts on
+// May 3.
+type GuideDate struct {
+	Time      time.Time
+	Precision string
+}
+
+// Format returns the date in ISO 8601 at its precision: "2015-05-03",
+// "2015-05" or "2015".
+func (d GuideDate) Format() string {
+	switch d.Precision {
+	case PrecisionMonth:
+		return d.Time.Format("2006-01")
+	case PrecisionYear:
+		return d.Time.Format("2006")
+	}
+	return d.Time.Format("2006-01-02")
+}
+
+var (
+	// citationPattern matches footnote markers such as "[1]", "[a]" and
+	// "[citation needed]".
+	citationPattern = regexp.MustCompile(`\[[^\]]*\]`)
+	ordinalPattern  = regexp.MustCompile(`(?i)\b(\d{1,2})(st|nd|rd|th)\b`)
+	isoDatePattern  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	isoMonthPattern = regexp.MustCompile(`^\d{4}-\d{2}$`)
+	rangePattern    = regexp.MustCompile(`\s*[–—]\s*|\s+-\s+|\s+to\s+|(\d)-(\d|[A-Za-z])`)
+	dateTokens      = regexp.MustCompile(`[A-Za-z]+|\d+`)
+)
+
+// ParseGuideDate parses a date as written in the Episode Guide. Besides
+// "November 15, 2015" it accepts abbreviated months with or without a
+// period ("Nov. 15, 2015", "Sept 6, 2015"), ordinal days ("November 15th,
+// 2015"), day-first dates ("15 November 2015"), ISO dates and months
+// ("2015-11"), ranges (the first day is used), a month and year ("May
+// 2015") or a year alone.
+// Footnote markers and trailing asterisks or daggers are ignored.
+func ParseGuideDate(s string) (GuideDate, error) {
+	clean := citationPattern.ReplaceAllString(s, "")
+	clean = strings.ReplaceAll(clean, " ", " ")
+	clean = strings.TrimRight(strings.TrimSpace(clean), "*†‡ ")
+	if clean == "" {
+		return GuideDate{}, fmt.Errorf("empty date %q", s)
+	}
+	if isoDatePattern.MatchString(clean) {
+		t, err := time.Parse("2006-01-02", clean)
+		if err != nil {
+			return GuideDate{}, fmt.Errorf("invalid date %q", s)
+		}
+		return GuideDate{Time: t, Precision: PrecisionDay}, nil
+	}
+	if isoMonthPattern.MatchString(clean) {
+		// "2015-11" is a month, not a range of years.
+		t, err := time.Parse("2006-01", clean)
+		if err != nil {
+			return GuideDate{}, fmt.Errorf("invalid date %q", s)
+		}
+		return GuideDate{Time: t, Precision: PrecisionMonth}, nil
+	}
+
+	// Keep the start of a range; its end supplies a missing month or year,
+	// as in "May 3–4, 2015" or "April 30 – May 2, 2015".
+	first, rest := clean, ""
+	if loc := rangePattern.FindStringSubmatchIndex(clean); loc != nil {
+		start, end := loc[0], loc[1]
+		if loc[2] >= 0 {
+			// "3-4": the digits around the hyphen belong to the dates.
+			start, end = loc[3], loc[4]
+		}
+		first, rest = clean[:start], clean[end:]
+	}
+
+	d, err := dateParts(first)
+	if err != nil {
+		return GuideDate{}, fmt.Errorf("parse date %q: %w", s, err)
+	}
+	if rest != "" {
+		end, err := dateParts(rest)
+		if err != nil {
+			return GuideDate{}, fmt.Errorf("parse date %q: %w", s, err)
+		}
+		if d.month == 0 && d.day != 0 {
+			d.month = end.month
+		}
+		if d.year == 0 {
+			d.year = end.year
+		}
+	}
+	return d.date(s)
+}
+
+// parts are the components found in a date; zero means missing.
+type parts struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateParts(s string) (parts, error) {
+	var p parts
+	s = ordinalPattern.ReplaceAllString(s, "$1")
+	for _, tok := range dateTokens.FindAllString(s, -1) {
+		if n, err := strconv.Atoi(tok); err == nil {
+			switch {
+			case len(tok) == 4 && p.year == 0:
+				p.year = n
+			case len(tok) <= 2 && p.day == 0 && n >= 1 && n <= 31:
+				p.day = n
+			default:
+				return parts{}, fmt.Errorf("unexpected number %q", tok)
+			}
+			continue
+		}
+		if strings.EqualFold(tok, "of") {
+			continue // "15th of November 2015"
+		}
+		m, ok := monthName(tok)
+		if !ok || p.month != 0 {
+			return parts{}, fmt.Errorf("unexpected word %q", tok)
+		}
+		p.month = m
+	}
+	return p, nil
+}
+
+// monthName recognizes a month by its full name or an abbreviation of at
+// least three letters, such as "Sep" or "Sept".
+func monthName(tok string) (time.Month, bool) {
+	if len(tok) < 3 {
+		return 0, false
+	}
+	tok = strings.ToLower(tok)
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToLower(m.String()), tok) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+func (p parts) date(s string) (GuideDate, error) {
+	switch {
+	case p.year == 0:
+		return GuideDate{}, fmt.Errorf("parse date %q: no year", s)
+	case p.month == 0 && p.day != 0:
+		return GuideDate{}, fmt.Errorf("parse date %q: day without a month", s)
+	case p.month == 0:
+		return GuideDate{Time: time.Date(p.year, time.January, 1, 0, 0, 0, 0, time.UTC), Precision: PrecisionYear}, nil
+	case p.day == 0:
+		return GuideDate{Time: time.Date(p.year, p.month, 1, 0, 0, 0, 0, time.UTC), Precision: PrecisionMonth}, nil
+	}
+	t := time.Date(p.year, p.month, p.day, 0, 0, 0, 0, time.UTC)
+	if t.Day() != p.day {
+		return GuideDate{}, fmt.Errorf("parse date %q: %s has no day %d", s, p.month, p.day)
+	}
+	return GuideDate{Time: t, Precision: PrecisionDay}, nil
+}
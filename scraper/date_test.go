@@ -0,0 +1,66 @@
+package scraper
+
+import "testing"
+
+func TestParseGuideDate(t *testing.T) {
+	tests := []struct {
+		in, want, precision string
+	}{
+		{"November 15, 2015", "2015-11-15", PrecisionDay},
+		{"Nov. 15, 2015", "2015-11-15", PrecisionDay},
+		{"Sept. 6, 2015", "2015-09-06", PrecisionDay},
+		{"sept 6 2015", "2015-09-06", PrecisionDay},
+		{"November 15th, 2015", "2015-11-15", PrecisionDay},
+		{"15th of November 2015", "2015-11-15", PrecisionDay},
+		{"2 May 2015", "2015-05-02", PrecisionDay},
+		{"2015-11-15", "2015-11-15", PrecisionDay},
+		{"May 3, 2015[1]", "2015-05-03", PrecisionDay},
+		{"May 3, 2015 [citation needed]", "2015-05-03", PrecisionDay},
+		{"May 3, 2015*", "2015-05-03", PrecisionDay},
+		{"May 3–4, 2015", "2015-05-03", PrecisionDay},
+		{"May 3-4, 2015", "2015-05-03", PrecisionDay},
+		{"3–4 May 2015", "2015-05-03", PrecisionDay},
+		{"April 30 – May 2, 2015", "2015-04-30", PrecisionDay},
+		{"December 31, 2015 to January 1, 2016", "2015-12-31", PrecisionDay},
+		{"May 2015", "2015-05", PrecisionMonth},
+		{"Sept. 2015", "2015-09", PrecisionMonth},
+		{"2015-11", "2015-11", PrecisionMonth},
+		{"2016", "2016", PrecisionYear},
+		{"2016†", "2016", PrecisionYear},
+	}
+	for _, tt := range tests {
+		d, err := ParseGuideDate(tt.in)
+		if err != nil {
+			t.Errorf("ParseGuideDate(%q): %v", tt.in, err)
+			continue
+		}
+		if got := d.Format(); got != tt.want || d.Precision != tt.precision {
+			t.Errorf("ParseGuideDate(%q) = %s (%s), want %s (%s)", tt.in, got, d.Precision, tt.want, tt.precision)
+		}
+	}
+}
+
+func TestParseGuideDateErrors(t *testing.T) {
+	for _, in := range []string{"", "TBA", "Date unknown", "May 3", "3, 2015", "February 30, 2016", "2015-02-30", "Ma 3, 2015", "[1]"} {
+		if d, err := ParseGuideDate(in); err == nil {
+			t.Errorf("ParseGuideDate(%q) = %v, want an error", in, d)
+		}
+	}
+}
+
+func TestEpisodeSetDate(t *testing.T) {
+	var e Episode
+	if err := e.SetDate("May 2015"); err != nil {
+		t.Fatal(err)
+	}
+	if e.FormattedDate != "2015-05" || e.DatePrecision != PrecisionMonth || e.DateStatus != DateOK || e.Timestamp.Month() != 5 {
+		t.Errorf("got %+v", e)
+	}
+
+	if err := e.SetDate("TBA"); err == nil {
+		t.Fatal("SetDate(TBA): want an error")
+	}
+	if e.Date != "TBA" || e.FormattedDate != "" || !e.Timestamp.IsZero() || e.DatePrecision != "" || e.DateStatus != DateUnknown {
+		t.Errorf("got %+v", e)
+	}
+}
@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Episode is one row of the Episode Guide.
+type Episode struct {
+	ID                 string    `bson:"_id,omitempty" json:"id"` // see assignIDs
+	Url                string    `bson:"url,omitempty" json:"url"`
+	Title              string    `bson:"title,omitempty" json:"title"`
+	EpisodeNo          string    `bson:"episode_no,omitempty" json:"episode_no"`
+	Numbering          Numbering `bson:"numbering" json:"numbering"`               // parsed from EpisodeNo
+	Season             string    `bson:"season,omitempty" json:"season,omitempty"` // heading of the guide table, e.g. a year or era
+	Date               string    `bson:"date,omitempty" json:"date"`
+	FormattedDate      string    `bson:"formatted_date,omitempty" json:"formatted_date"`
+	Timestamp          time.Time `bson:"timestamp" json:"timestamp"`
+	DatePrecision      string    `bson:"date_precision,omitempty" json:"date_precision,omitempty"` // PrecisionDay, PrecisionMonth or PrecisionYear
+	DateStatus         string    `bson:"date_status,omitempty" json:"date_status,omitempty"`       // DateOK or DateUnknown
+	Guests             []string  `bson:"guests,omitempty" json:"guests"`                           // canonical names of GuestRefs
+	Top5ComparisonYear string    `bson:"top_5_comparison_year,omitempty" json:"top_5_comparison_year"`
+	Notes              string    `bson:"notes,omitempty" json:"notes"` // plain text, without footnote markers
+
+	// NoteLinks and NoteFootnotes are the links and citations in the Notes
+	// column.
+	NoteLinks     []Link     `bson:"note_links,omitempty" json:"note_links,omitempty"`
+	NoteFootnotes []Footnote `bson:"note_footnotes,omitempty" json:"note_footnotes,omitempty"`
+
+	// GuestRefs are the normalized guests with their IDs and wiki pages,
+	// and RawGuests the fragments of the Guests cell as written.
+	GuestRefs []Guest  `bson:"guest_refs,omitempty" json:"guest_refs,omitempty"`
+	RawGuests []string `bson:"raw_guests,omitempty" json:"raw_guests,omitempty"`
+
+	// Content is parsed from the episode's own page by CrawlEpisodePages.
+	Content *TCContentSpec `bson:"content,omitempty" json:"content,omitempty"`
+}
+
+// episodeID returns an ID that survives edits to an episode's title and
+// link and rows added around it. Numbered episodes are keyed on the parsed
+// parts of their number, so "104 Part 2 (Rerun)" is always the same
+// episode. Unnumbered ones, such as "Special", are keyed on the season,
+// the number cell as written and the wiki page they link to, or their
+// title when they link nowhere.
+func episodeID(e Episode) string {
+	n := e.Numbering
+	var key string
+	if n.Number > 0 {
+		key = fmt.Sprintf("%d\x00%s\x00%d\x00%d\x00%s", n.Number, n.Suffix, n.Part, n.Through, n.Kind)
+	} else {
+		page := ""
+		if u, err := url.Parse(e.Url); err == nil && e.Url != "" {
+			page = wikiTitle(u)
+		}
+		if page == "" {
+			page = e.Title
+		}
+		key = strings.Join([]string{"~", e.Season, normalizeKey(e.EpisodeNo), normalizeKey(page)}, "\x00")
+	}
+	hash := md5.Sum([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// normalizeKey lower-cases s and collapses its whitespace.
+func normalizeKey(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// SetDate sets Date to the guide's date string and derives FormattedDate,
+// Timestamp, DatePrecision and DateStatus from it. When the date cannot be
+// parsed the episode keeps the raw Date, is marked DateUnknown and the
+// error is returned.
+func (e *Episode) SetDate(date string) error {
+	e.Date = date
+	d, err := ParseGuideDate(date)
+	if err != nil {
+		e.FormattedDate, e.Timestamp, e.DatePrecision, e.DateStatus = "", time.Time{}, "", DateUnknown
+		return err
+	}
+	e.FormattedDate, e.Timestamp, e.DatePrecision, e.DateStatus = d.Format(), d.Time, d.Precision, DateOK
+	return nil
+}
+
+// ParseDate parses a guide date such as "November 15, 2015" or "Sept. 2015"
+// and returns the first day it covers. See ParseGuideDate.
+func ParseDate(dateStr string) (time.Time, error) {
+	d, err := ParseGuideDate(dateStr)
+	return d.Time, err
+}
+
+// FormatDate converts "November 15, 2015" → "2015-11-15", and a date known
+// only to the month or year to "2015-11" or "2015".
+func FormatDate(dateStr string) (string, error) {
+	d, err := ParseGuideDate(dateStr)
+	if err != nil {
+		return "", err
+	}
+	return d.Format(), nil
+}
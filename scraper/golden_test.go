@@ -0,0 +1,126 @@
+package scraper
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenEpisode is the part of an Episode the golden files pin down.
+type goldenEpisode struct {
+	EpisodeNo          string     `json:"episode_no"`
+	Numbering          Numbering  `json:"numbering"`
+	Season             string     `json:"season"`
+	Title              string     `json:"title"`
+	Url                string     `json:"url"`
+	Date               string     `json:"date"`
+	FormattedDate      string     `json:"formatted_date"`
+	DatePrecision      string     `json:"date_precision"`
+	DateStatus         string     `json:"date_status"`
+	Guests             []string   `json:"guests"`
+	GuestRefs          []Guest    `json:"guest_refs"`
+	RawGuests          []string   `json:"raw_guests"`
+	Top5ComparisonYear string     `json:"top_5_comparison_year"`
+	Notes              string     `json:"notes"`
+	NoteLinks          []Link     `json:"note_links"`
+	NoteFootnotes      []Footnote `json:"note_footnotes"`
+}
+
+// TestEpisodeGuideGolden parses every testdata/episode_guide*.html and
+// compares the result with testdata/golden/<name>.json. After a deliberate
+// parser change, regenerate the files with
+//
+//	go test ./scraper -run Golden -update
+//
+// and review the diff.
+func TestEpisodeGuideGolden(t *testing.T) {
+	files, err := filepath.Glob("testdata/episode_guide*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no episode guide fixtures found")
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".html")
+		t.Run(name, func(t *testing.T) {
+			episodes, _ := scrapeFixture(t, filepath.Base(file))
+
+			got := make([]goldenEpisode, len(episodes))
+			for i, e := range episodes {
+				got[i] = goldenEpisode{
+					EpisodeNo:          e.EpisodeNo,
+					Numbering:          e.Numbering,
+					Season:             e.Season,
+					Title:              e.Title,
+					Url:                e.Url,
+					Date:               e.Date,
+					FormattedDate:      e.FormattedDate,
+					DatePrecision:      e.DatePrecision,
+					DateStatus:         e.DateStatus,
+					Guests:             e.Guests,
+					GuestRefs:          e.GuestRefs,
+					RawGuests:          e.RawGuests,
+					Top5ComparisonYear: e.Top5ComparisonYear,
+					Notes:              e.Notes,
+					NoteLinks:          e.NoteLinks,
+					NoteFootnotes:      e.NoteFootnotes,
+				}
+			}
+			data, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, '\n')
+
+			golden := filepath.Join("testdata", "golden", name+".json")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(data, want) {
+				t.Errorf("%s does not match %s (run with -update and review the diff):\n%s",
+					file, golden, lineDiff(string(want), string(data)))
+			}
+		})
+	}
+}
+
+// lineDiff lists the first lines that differ between want and got.
+func lineDiff(want, got string) string {
+	w, g := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var b strings.Builder
+	shown := 0
+	for i := 0; (i < len(w) || i < len(g)) && shown < 20; i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl {
+			fmt.Fprintf(&b, "line %d:\n  - %s\n  + %s\n", i+1, wl, gl)
+			shown++
+		}
+	}
+	return b.String()
+}
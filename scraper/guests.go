@@ -0,0 +1,211 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Guest is one person appearing on an episode, after normalization.
+type Guest struct {
+	// ID is a stable slug of the canonical name, e.g. "jake-longstreth".
+	ID string `bson:"id" json:"id"`
+
+	// Name is the canonical display name.
+	Name string `bson:"name" json:"name"`
+
+	// URL is the guest's wiki page, when the guide links to one that exists.
+	URL string `bson:"url,omitempty" json:"url,omitempty"`
+}
+
+// Aliases maps alternative spellings and link texts of a guest's name to
+// their canonical name. Keys are matched ignoring case, spacing and
+// surrounding punctuation.
+type Aliases map[string]string
+
+// LoadAliases reads an alias table from a JSON file holding a single
+// object, e.g. {"Jake": "Jake Longstreth", "J. Longstreth": "Jake Longstreth"}.
+func LoadAliases(path string) (Aliases, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var aliases Aliases
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return aliases, nil
+}
+
+// Canonical returns the canonical name for name, or name itself, cleaned,
+// when it has no alias.
+func (a Aliases) Canonical(name string) string {
+	name = cleanGuest(name)
+	key := aliasKey(name)
+	for alias, canonical := range a {
+		if aliasKey(alias) == key {
+			return cleanGuest(canonical)
+		}
+	}
+	return name
+}
+
+func aliasKey(name string) string {
+	return strings.ToLower(cleanGuest(name))
+}
+
+// GuestID returns the slug used as a guest's ID: the lowercased name with
+// every run of other characters than letters and digits replaced by "-".
+func GuestID(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else if r != '\'' && r != '’' {
+			// Apostrophes are dropped so "O'Brien" becomes "obrien".
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// guestEntry is one guest as written in the guide, before normalization.
+type guestEntry struct {
+	text string
+	link *url.URL // the <a> href, or nil for plain text
+	page bool     // the link points at an existing page
+}
+
+// guestSeparator splits plain-text guest lists such as
+// "Rostam, Hamilton Leithauser and Chris Tomson".
+var guestSeparator = regexp.MustCompile(`(?i)\s*(?:[,;&+/]|\band\b)\s*`)
+
+// parseGuests returns the raw fragments of a Guests cell and the guest
+// entries in them. Each <a> is one guest; <span>s and text nodes between
+// <br> tags may hold several, separated by commas, "&" or "and". A cell
+// holding only "—" has no guests.
+func parseGuests(cell *goquery.Selection, base *url.URL) ([]string, []guestEntry) {
+	if text := strings.TrimSpace(cell.Text()); text == "" || text == "—" {
+		return nil, nil
+	}
+
+	var raw []string
+	var entries []guestEntry
+	cell.Contents().Each(func(_ int, s *goquery.Selection) {
+		if !s.Is("a") && !s.Is("span") && goquery.NodeName(s) != "#text" {
+			return
+		}
+		text := strings.TrimSpace(s.Text())
+		if text == "" {
+			return
+		}
+		raw = append(raw, text)
+
+		if s.Is("a") {
+			e := guestEntry{text: text, page: !s.HasClass("new")}
+			if href := resolveLink(base, s); href != "" {
+				e.link, _ = url.Parse(href)
+			}
+			entries = append(entries, e)
+			return
+		}
+		for _, part := range guestSeparator.Split(text, -1) {
+			entries = append(entries, guestEntry{text: part})
+		}
+	})
+	return raw, entries
+}
+
+// normalizeGuests resolves guest entries to canonical guests, dropping
+// fragments that are only punctuation and repeats of the same person.
+// A wiki link names the guest by its page title, which is more reliable
+// than the link text; aliases then map either to the canonical name.
+func normalizeGuests(entries []guestEntry, aliases Aliases) []Guest {
+	var guests []Guest
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		name := e.text
+		if title := wikiPageTitle(e.link); title != "" {
+			name = title
+		}
+		name = aliases.Canonical(name)
+		id := GuestID(name)
+		if id == "" || id == "and" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		g := Guest{ID: id, Name: name}
+		if e.link != nil && e.page {
+			g.URL = e.link.String()
+		}
+		guests = append(guests, g)
+	}
+	return guests
+}
+
+// disambiguation matches a trailing " (musician)" in a page title.
+var disambiguation = regexp.MustCompile(`\s*\([^)]*\)$`)
+
+// wikiPageTitle returns the page title a wiki link points at, without any
+// disambiguation suffix, or "" for links outside /wiki/ and pages in a
+// namespace such as Category:.
+func wikiPageTitle(link *url.URL) string {
+	title := wikiTitle(link)
+	if strings.Contains(title, ":") {
+		// Special:, Category: and other namespaces are not people.
+		return ""
+	}
+	return strings.TrimSpace(disambiguation.ReplaceAllString(title, ""))
+}
+
+// wikiTitle returns the full title of the page a wiki link points at, or
+// "" for links outside /wiki/. Links to missing pages look like
+// /index.php?title=Ben_Stiller&action=edit&redlink=1.
+func wikiTitle(link *url.URL) string {
+	if link == nil {
+		return ""
+	}
+	var title string
+	switch {
+	case strings.HasPrefix(link.Path, "/wiki/"):
+		title = strings.TrimPrefix(link.Path, "/wiki/")
+	case link.Query().Get("title") != "":
+		title = link.Query().Get("title")
+	default:
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(title, "_", " "))
+}
+
+// cleanGuest trims stray separators around a name and collapses runs of
+// whitespace.
+func cleanGuest(name string) string {
+	name = strings.TrimFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`,;:&+/-–—•*"“”`, r)
+	})
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// guestNames lists the names of guests.
+func guestNames(guests []Guest) []string {
+	if len(guests) == 0 {
+		return nil
+	}
+	names := make([]string, len(guests))
+	for i, g := range guests {
+		names[i] = g.Name
+	}
+	return names
+}
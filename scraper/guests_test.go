@@ -0,0 +1,50 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGuestID(t *testing.T) {
+	tests := map[string]string{
+		"Jake Longstreth":    "jake-longstreth",
+		"  Conan O'Brien ":   "conan-obrien",
+		"Tyler, the Creator": "tyler-the-creator",
+		"Beyoncé":            "beyoncé",
+		"—":                  "",
+	}
+	for name, want := range tests {
+		if got := GuestID(name); got != want {
+			t.Errorf("GuestID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestNormalizeGuestsAliases(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "aliases.json")
+	if err := os.WriteFile(path, []byte(`{"Jake": "Jake Longstreth", "rostam batmanglij": "Rostam"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	aliases, err := LoadAliases(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []guestEntry{
+		{text: "JAKE"},
+		{text: "Rostam  Batmanglij,"},
+		{text: "Jake Longstreth"}, // same person as "JAKE" once resolved
+		{text: "and"},
+		{text: ","},
+	}
+	want := []Guest{
+		{ID: "jake-longstreth", Name: "Jake Longstreth"},
+		{ID: "rostam", Name: "Rostam"},
+	}
+	if got := normalizeGuests(entries, aliases); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,235 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+)
+
+// EpisodeGuideURL is the wiki page listing every episode.
+const EpisodeGuideURL = "https://the-time-crisis-universe.fandom.com/wiki/Episode_Guide"
+
+// ErrNoTables is returned when the page has no episode tables, which usually
+// means the wiki layout changed.
+var ErrNoTables = errors.New("no .article-table found on episode guide")
+
+// Options configures ScrapeEpisodeGuide and CrawlEpisodePages.
+type Options struct {
+	// Reader supplies the Episode Guide HTML. When nil, URL is fetched.
+	Reader io.Reader
+
+	// URL of the Episode Guide. Defaults to EpisodeGuideURL.
+	URL string
+
+	// Transport is used for HTTP requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	// Parallelism is the number of episode pages fetched at once. Defaults to 2.
+	Parallelism int
+
+	// Aliases maps alternative guest names to canonical ones.
+	Aliases Aliases
+
+	// StrictSchema makes ScrapeEpisodeGuide fail with a *SchemaError for
+	// every guide table whose header row is missing an expected column,
+	// has a new one or is absent. Otherwise the differences are passed to
+	// Warn and the tables are parsed as well as their headers allow.
+	// Renamed headers are always only passed to Warn.
+	StrictSchema bool
+
+	// Warn is called for rows and pages that are skipped, for rows whose
+	// date cannot be parsed and for schema drift. It may be nil.
+	Warn func(error)
+}
+
+func (o *Options) warn(format string, args ...interface{}) {
+	if o.Warn != nil {
+		o.Warn(fmt.Errorf(format, args...))
+	}
+}
+
+// ScrapeEpisodeGuide parses every episode in the Episode Guide tables.
+func ScrapeEpisodeGuide(ctx context.Context, opts Options) ([]Episode, error) {
+	if opts.URL == "" {
+		opts.URL = EpisodeGuideURL
+	}
+	base, err := url.Parse(opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse episode guide URL: %w", err)
+	}
+
+	if opts.Reader != nil {
+		doc, err := goquery.NewDocumentFromReader(opts.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("parse episode guide: %w", err)
+		}
+		tables := doc.Find(".article-table")
+		if tables.Length() == 0 {
+			return nil, ErrNoTables
+		}
+		var episodes []Episode
+		var drift []error
+		tables.Each(func(i int, table *goquery.Selection) {
+			parsed, err := parseGuideTable(table, i+1, base, &opts)
+			episodes = append(episodes, parsed...)
+			if err != nil {
+				drift = append(drift, err)
+			}
+		})
+		if len(drift) > 0 {
+			return nil, errors.Join(drift...)
+		}
+		return episodes, ctx.Err()
+	}
+
+	c := newCollector(ctx, opts.Transport)
+
+	var episodes []Episode
+	var drift []error
+	tables := 0
+	c.OnHTML(".article-table", func(e *colly.HTMLElement) {
+		tables++
+		parsed, err := parseGuideTable(e.DOM, tables, base, &opts)
+		episodes = append(episodes, parsed...)
+		if err != nil {
+			drift = append(drift, err)
+		}
+	})
+
+	if err := c.Visit(opts.URL); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("visit %s: %w", opts.URL, err)
+	}
+	if tables == 0 {
+		return nil, ErrNoTables
+	}
+	if len(drift) > 0 {
+		return nil, errors.Join(drift...)
+	}
+	return episodes, nil
+}
+
+// parseGuideTable extracts an Episode from every row of an .article-table.
+// Cells are found by the column names in the header row, so extra or
+// reordered columns don't shift them; a table without a header row uses
+// the usual order. Every episode is tagged with the heading before the
+// table. Links are resolved against base, the URL of the guide itself.
+//
+// A header that differs from the expected columns is reported as a
+// *SchemaError through opts.Warn, or returned when opts.StrictSchema is
+// set and a column is missing or new. n is the table's position on the page, for the report.
+func parseGuideTable(table *goquery.Selection, n int, base *url.URL, opts *Options) ([]Episode, error) {
+	var episodes []Episode
+	season := tableHeading(table)
+	var header *guideHeader
+	rows := table.Find("tr").FilterFunction(func(_ int, row *goquery.Selection) bool {
+		h, ok := parseHeader(row)
+		if ok && header == nil {
+			header = &h
+		}
+		return !ok
+	})
+	if drift := checkSchema(n, season, header); drift != nil {
+		if opts.StrictSchema && !drift.renamedOnly() {
+			return nil, drift
+		}
+		opts.warn("%w", drift)
+	}
+	cols := defaultColumns
+	if header != nil {
+		cols = header.cols
+	}
+
+	rows.Each(func(i int, row *goquery.Selection) {
+		// Spacer rows, colspan banners such as "Hiatus" and empty <tr>s
+		// are not episodes. th cells count, as in cell(), since some rows
+		// are numbered in a row-header <th>.
+		if cells := row.Children().Length(); cells < cols.width() {
+			opts.warn("table %d row %d: skipping row with %d of %d cells: %q", n, i+1, cells, cols.width(), cleanText(row))
+			return
+		}
+
+		// Extract data from the row
+		episodeNo := cols.text(row, columnEpisode)
+		title := cols.text(row, columnTitle)
+		if episodeNo == "" && title == "" {
+			opts.warn("table %d row %d: skipping row without an episode number or title", n, i+1)
+			return
+		}
+		episodeURL := resolveLink(base, cols.cell(row, columnTitle).Find("a[href]").First())
+		date := cols.text(row, columnDate)
+		rawGuests, entries := parseGuests(cols.cell(row, columnGuests), base)
+		guests := normalizeGuests(entries, opts.Aliases)
+		top5ComparisonYear := cols.text(row, columnTop5)
+		notes, noteLinks, noteFootnotes := parseNotes(cols.cell(row, columnNotes), base)
+
+		episode := Episode{
+			Url:                episodeURL,
+			Title:              title,
+			EpisodeNo:          episodeNo,
+			Numbering:          ParseEpisodeNo(episodeNo),
+			Season:             season,
+			Guests:             guestNames(guests),
+			GuestRefs:          guests,
+			RawGuests:          rawGuests,
+			Top5ComparisonYear: top5ComparisonYear,
+			Notes:              notes,
+			NoteLinks:          noteLinks,
+			NoteFootnotes:      noteFootnotes,
+		}
+		episode.ID = episodeID(episode)
+		if err := episode.SetDate(date); err != nil {
+			// Keep the episode; its date_status tells readers the date is unknown.
+			opts.warn("episode %s: %w; storing it with an unknown date", episodeNo, err)
+		}
+		episodes = append(episodes, episode)
+	})
+	return episodes, nil
+}
+
+// resolveLink returns the absolute URL of an anchor's href, or "" when the
+// anchor is missing or its href cannot be parsed.
+func resolveLink(base *url.URL, a *goquery.Selection) string {
+	href, ok := a.Attr("href")
+	if !ok || strings.TrimSpace(href) == "" {
+		return ""
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// newCollector returns a colly collector whose requests are bound to ctx.
+func newCollector(ctx context.Context, transport http.RoundTripper) *colly.Collector {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	c := colly.NewCollector()
+	c.WithTransport(contextTransport{ctx: ctx, base: transport})
+	return c
+}
+
+// contextTransport attaches ctx to every request so colly fetches can be
+// cancelled.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
@@ -0,0 +1,260 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func scrapeFixture(t *testing.T, name string) ([]Episode, []error) {
+	t.Helper()
+
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var warnings []error
+	episodes, err := ScrapeEpisodeGuide(context.Background(), Options{
+		Reader: f,
+		Warn:   func(err error) { warnings = append(warnings, err) },
+	})
+	if err != nil {
+		t.Fatalf("ScrapeEpisodeGuide: %v", err)
+	}
+	return episodes, warnings
+}
+
+func TestScrapeEpisodeGuideGuestsStayOnTheirRow(t *testing.T) {
+	episodes, warnings := scrapeFixture(t, "episode_guide.html")
+
+	if len(warnings) != 0 {
+		t.Errorf("got %d warnings, want none: %v", len(warnings), warnings)
+	}
+
+	want := map[string][]string{
+		"1":  {"Jake Longstreth"},
+		"2":  nil, // "—" means no guests
+		"3":  {"Chris Baio"},
+		"4":  {"Jake Longstreth", "Jonah Hill", "Mystery caller"},
+		"20": {"Seth Rogen"},
+		"21": {"Jake Longstreth", "Jason Schwartzman"},
+	}
+
+	if len(episodes) != len(want) {
+		t.Fatalf("got %d episodes, want %d", len(episodes), len(want))
+	}
+	for _, e := range episodes {
+		guests, ok := want[e.EpisodeNo]
+		if !ok {
+			t.Errorf("unexpected episode %q", e.EpisodeNo)
+			continue
+		}
+		if !reflect.DeepEqual(e.Guests, guests) {
+			t.Errorf("episode %s guests = %q, want %q", e.EpisodeNo, e.Guests, guests)
+		}
+	}
+}
+
+func TestScrapeEpisodeGuideNoTables(t *testing.T) {
+	_, err := ScrapeEpisodeGuide(context.Background(), Options{
+		Reader: strings.NewReader("<html><body><p>Nothing here</p></body></html>"),
+	})
+	if err != ErrNoTables {
+		t.Fatalf("err = %v, want ErrNoTables", err)
+	}
+}
+
+func TestScrapeEpisodeGuideResolvesLinkHref(t *testing.T) {
+	episodes, _ := scrapeFixture(t, "episode_guide.html")
+
+	want := map[string]string{
+		"1": "https://the-time-crisis-universe.fandom.com/wiki/The_Rise_of_the_Crisis",
+		"2": "https://the-time-crisis-universe.fandom.com/wiki/Tom_Petty%27s_Son",
+		"4": "https://the-time-crisis-universe.fandom.com/wiki/Who_Is_the_Rolling_Stones%3F",
+	}
+	for _, e := range episodes {
+		if url, ok := want[e.EpisodeNo]; ok && e.Url != url {
+			t.Errorf("episode %s url = %q, want %q", e.EpisodeNo, e.Url, url)
+		}
+	}
+}
+
+func TestScrapeEpisodeGuideKeepsUnknownDates(t *testing.T) {
+	episodes, warnings := scrapeFixture(t, "episode_guide_dates.html")
+
+	// "TBA" and the empty date are warned about but still stored.
+	if len(warnings) != 2 {
+		t.Errorf("got %d warnings, want 2: %v", len(warnings), warnings)
+	}
+	status := make(map[string]string)
+	for _, e := range episodes {
+		status[e.EpisodeNo] = e.DateStatus
+		if e.DateStatus == DateUnknown && (!e.Timestamp.IsZero() || e.FormattedDate != "") {
+			t.Errorf("episode %s: unknown date has timestamp %v, formatted date %q", e.EpisodeNo, e.Timestamp, e.FormattedDate)
+		}
+	}
+	want := map[string]string{
+		"30": DateOK, "31": DateOK, "32": DateOK, "33": DateOK,
+		"34": DateOK, "35": DateOK, "36": DateUnknown, "37": DateUnknown,
+	}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("date statuses = %v, want %v", status, want)
+	}
+}
+
+func TestScrapeEpisodeGuideSchemaDrift(t *testing.T) {
+	_, warnings := scrapeFixture(t, "episode_guide_seasons.html")
+	var drift []*SchemaError
+	for _, w := range warnings {
+		var se *SchemaError
+		if errors.As(w, &se) {
+			drift = append(drift, se)
+		}
+	}
+	want := []*SchemaError{
+		{Table: 1, Heading: "Season 1", Unexpected: []string{"Length"}, Renamed: []HeaderRename{
+			{Expected: "Episode", Found: "Ep."},
+			{Expected: "Title", Found: "Episode Title"},
+		}},
+		{Table: 2, Heading: "Apple Music era", NoHeader: true},
+	}
+	if !reflect.DeepEqual(drift, want) {
+		t.Errorf("got %+v, want %+v", drift, want)
+	}
+
+	f, err := os.Open("testdata/episode_guide_seasons.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	episodes, err := ScrapeEpisodeGuide(context.Background(), Options{Reader: f, StrictSchema: true})
+	if err == nil || episodes != nil {
+		t.Fatalf("strict: got %d episodes, err %v; want a schema error", len(episodes), err)
+	}
+	for _, s := range []string{`table 1 ("Season 1"): header row changed`, "+ Length (new)", "~ Ep. (read as Episode)", `table 2 ("Apple Music era"): no header row`} {
+		if !strings.Contains(err.Error(), s) {
+			t.Errorf("strict error %q does not mention %q", err, s)
+		}
+	}
+}
+
+func TestScrapeEpisodeGuideMissingColumn(t *testing.T) {
+	html := `<h2>2019</h2><table class="article-table"><tr><th>Episode</th><th>Title</th><th>Guests</th><th>Year</th></tr>
+<tr><td>150</td><td>Crisis</td><td>Jake Longstreth</td><td>1984</td></tr></table>`
+	_, err := ScrapeEpisodeGuide(context.Background(), Options{Reader: strings.NewReader(html), StrictSchema: true})
+	var se *SchemaError
+	if !errors.As(err, &se) {
+		t.Fatalf("err = %v, want a *SchemaError", err)
+	}
+	want := &SchemaError{Table: 1, Heading: "2019", Missing: []string{"Date", "Top 5 Comparison Year", "Notes"}, Unexpected: []string{"Year"}}
+	if !reflect.DeepEqual(se, want) {
+		t.Errorf("got %+v, want %+v", se, want)
+	}
+
+	// Without StrictSchema the known columns are still read.
+	episodes, err := ScrapeEpisodeGuide(context.Background(), Options{Reader: strings.NewReader(html)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(episodes) != 1 || episodes[0].Title != "Crisis" || episodes[0].Guests[0] != "Jake Longstreth" || episodes[0].Notes != "" {
+		t.Errorf("got %+v", episodes)
+	}
+}
+
+func TestScrapeEpisodeGuideSkipsSpacerRows(t *testing.T) {
+	episodes, warnings := scrapeFixture(t, "episode_guide_spacers.html")
+
+	// The empty row, the "Hiatus" banner and the row without a number or
+	// title are skipped with a warning each.
+	if len(warnings) != 3 {
+		t.Errorf("got %d warnings, want 3: %v", len(warnings), warnings)
+	}
+	var got []string
+	for _, e := range episodes {
+		got = append(got, e.EpisodeNo)
+	}
+	if want := []string{"180", "181", "182"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got episodes %q, want %q", got, want)
+	}
+}
+
+func TestScrapeEpisodeGuideIDsSurviveEdits(t *testing.T) {
+	scrape := func(html string) []string {
+		t.Helper()
+		episodes, err := ScrapeEpisodeGuide(context.Background(), Options{Reader: strings.NewReader(html)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, e := range episodes {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+	const page = `<h2>%s</h2><table class="article-table">
+<tr><td>104</td><td><a href="/wiki/%s">%s</a></td><td>March 4, 2018</td><td></td><td></td><td></td></tr>
+<tr><td>52 (Rerun)</td><td>Yacht Rock</td><td>March 18, 2018</td><td></td><td></td><td></td></tr>
+<tr><td>Special</td><td>Christmas Crisis</td><td>December 24, 2017</td><td></td><td></td><td></td></tr>
+<tr><td>Special</td><td>New Year's Crisis</td><td>December 31, 2017</td><td></td><td></td><td></td></tr>
+</table>`
+
+	before := scrape(fmt.Sprintf(page, "2018", "Crisis", "Crisis"))
+	after := scrape(fmt.Sprintf(page, "2018", "Crisis_(episode)", "Crisis!"))
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("IDs changed with the title and link: %q, then %q", before, after)
+	}
+	seen := make(map[string]bool)
+	for _, id := range before {
+		if seen[id] {
+			t.Errorf("duplicate ID %s in %q", id, before)
+		}
+		seen[id] = true
+	}
+
+	// Unnumbered episodes are told apart by season.
+	other := scrape(fmt.Sprintf(page, "2019", "Crisis", "Crisis"))
+	if other[0] != before[0] || other[2] == before[2] {
+		t.Errorf("2018 IDs %q, 2019 IDs %q: want only the specials to differ", before, other)
+	}
+
+	// A special and an episode added at the top leave the other IDs alone.
+	inserted := strings.Replace(fmt.Sprintf(page, "2018", "Crisis", "Crisis"), `<table class="article-table">`, `<table class="article-table">
+<tr><td>Special</td><td>Halloween Crisis</td><td>October 31, 2017</td><td></td><td></td><td></td></tr>
+<tr><td>103</td><td>Prequel</td><td>February 25, 2018</td><td></td><td></td><td></td></tr>`, 1)
+	if got := scrape(inserted)[2:]; !reflect.DeepEqual(got, before) {
+		t.Errorf("IDs after inserting rows = %q, want %q", got, before)
+	}
+}
+
+func TestScrapeEpisodeGuideRenamedHeaders(t *testing.T) {
+	html := `<table class="article-table"><tr><th>Episode</th><th>Title</th><th>Air date</th><th>Featuring</th><th>Top 5 Comparison Year</th><th>Notes</th></tr>
+<tr><td>150</td><td>Crisis</td><td>May 3, 2019</td><td>Jake Longstreth</td><td>1984</td><td></td></tr></table>`
+
+	var warnings []error
+	episodes, err := ScrapeEpisodeGuide(context.Background(), Options{
+		Reader:       strings.NewReader(html),
+		StrictSchema: true,
+		Warn:         func(err error) { warnings = append(warnings, err) },
+	})
+	if err != nil {
+		t.Fatalf("renamed headers failed a strict scrape: %v", err)
+	}
+	if len(episodes) != 1 || episodes[0].Date != "May 3, 2019" || episodes[0].Guests[0] != "Jake Longstreth" {
+		t.Errorf("got %+v", episodes)
+	}
+
+	var se *SchemaError
+	if len(warnings) != 1 || !errors.As(warnings[0], &se) {
+		t.Fatalf("warnings = %v, want one *SchemaError", warnings)
+	}
+	want := []HeaderRename{{Expected: "Date", Found: "Air date"}, {Expected: "Guests", Found: "Featuring"}}
+	if !reflect.DeepEqual(se.Renamed, want) {
+		t.Errorf("renamed = %+v, want %+v", se.Renamed, want)
+	}
+}
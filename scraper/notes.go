@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Link is a link in the Notes column, kept so that readers can render
+// cross-references and build a graph of the wiki pages episodes mention.
+type Link struct {
+	// Title is the wiki page the link points at, e.g. "Jake's Corner", or
+	// "" for a link off the wiki.
+	Title string `bson:"title,omitempty" json:"title,omitempty"`
+
+	// Href is the absolute URL of the link.
+	Href string `bson:"href" json:"href"`
+
+	// Text is the anchor text as shown in the notes.
+	Text string `bson:"text" json:"text"`
+
+	// Missing is set for a red link, whose wiki page doesn't exist yet.
+	Missing bool `bson:"missing,omitempty" json:"missing,omitempty"`
+}
+
+// Footnote is a citation attached to the Notes column, such as the "[1]"
+// after a sentence.
+type Footnote struct {
+	// Marker is the footnote's label without brackets, e.g. "1".
+	Marker string `bson:"marker" json:"marker"`
+
+	// Text is the footnote from the page's references list, or "" when the
+	// page has no matching entry.
+	Text string `bson:"text,omitempty" json:"text,omitempty"`
+
+	// Links are the links in the footnote's text.
+	Links []Link `bson:"links,omitempty" json:"links,omitempty"`
+}
+
+// parseNotes returns the plain text of a Notes cell, without footnote
+// markers, and the links and footnotes in it, each footnote once. Footnote
+// text is looked up in the references list of the document the cell
+// belongs to.
+func parseNotes(cell *goquery.Selection, base *url.URL) (string, []Link, []Footnote) {
+	text := cleanText(cell)
+	body := cell.Clone()
+	body.Find("sup.reference").Remove()
+	links := parseLinks(body, base)
+
+	var footnotes []Footnote
+	seen := make(map[string]bool)
+	root := cell.Parents().Last()
+	cell.Find("sup.reference").Each(func(_ int, sup *goquery.Selection) {
+		f := Footnote{Marker: strings.Trim(strings.TrimSpace(sup.Text()), "[]")}
+		if seen[f.Marker] {
+			return // the same citation used twice
+		}
+		seen[f.Marker] = true
+		href, _ := sup.Find("a[href]").Attr("href")
+		if id, ok := strings.CutPrefix(href, "#"); ok && id != "" {
+			ref := root.Find("li").FilterFunction(func(_ int, li *goquery.Selection) bool {
+				return li.AttrOr("id", "") == id
+			}).First().Clone()
+			ref.Find(".mw-cite-backlink").Remove()
+			if body := ref.Find(".reference-text"); body.Length() > 0 {
+				ref = body
+			}
+			if ref.Length() > 0 {
+				f.Text = cleanText(ref)
+				f.Links = parseLinks(ref, base)
+			}
+		}
+		footnotes = append(footnotes, f)
+	})
+	return text, links, footnotes
+}
+
+// parseLinks returns every link in s except in-page anchors. Links to the
+// wiki itself, the host of base, get their page title.
+func parseLinks(s *goquery.Selection, base *url.URL) []Link {
+	var links []Link
+	s.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		if strings.HasPrefix(strings.TrimSpace(a.AttrOr("href", "")), "#") {
+			return
+		}
+		href := resolveLink(base, a)
+		if href == "" {
+			return
+		}
+		link := Link{Href: href, Text: cleanText(a), Missing: a.HasClass("new")}
+		if u, err := url.Parse(href); err == nil && u.Host == base.Host {
+			link.Title = wikiTitle(u)
+		}
+		links = append(links, link)
+	})
+	return links
+}
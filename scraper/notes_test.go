@@ -0,0 +1,32 @@
+package scraper
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseNotesUnresolvedFootnote(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<table><tr><td id="notes">Live <b>from</b> <a href="/wiki/Lollapalooza" title="Lollapalooza">Lolla</a>.<sup class="reference"><a href="#cite_note-9">[9]</a></sup><sup class="reference"><a href="#cite_note-9">[9]</a></sup></td></tr></table>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse(EpisodeGuideURL)
+
+	text, links, footnotes := parseNotes(doc.Find("#notes"), base)
+	if text != "Live from Lolla." {
+		t.Errorf("text = %q", text)
+	}
+	wantLinks := []Link{{Title: "Lollapalooza", Href: "https://the-time-crisis-universe.fandom.com/wiki/Lollapalooza", Text: "Lolla"}}
+	if !reflect.DeepEqual(links, wantLinks) {
+		t.Errorf("links = %+v, want %+v", links, wantLinks)
+	}
+	// The page has no references list, so only the marker is known.
+	wantFootnotes := []Footnote{{Marker: "9"}}
+	if !reflect.DeepEqual(footnotes, wantFootnotes) {
+		t.Errorf("footnotes = %+v, want %+v", footnotes, wantFootnotes)
+	}
+}
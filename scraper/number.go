@@ -0,0 +1,164 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Episode kinds.
+const (
+	KindRegular     = "regular"
+	KindSpecial     = "special"
+	KindRerun       = "rerun"
+	KindCompilation = "compilation"
+)
+
+// Kinds lists every episode kind.
+var Kinds = []string{KindRegular, KindSpecial, KindRerun, KindCompilation}
+
+// Numbering is the structured form of an episode number such as "104A".
+type Numbering struct {
+	// Number is the numeric part, 104 for "104A"; 0 when there is none,
+	// as for "Special" or a blank cell.
+	Number int `bson:"number,omitempty" json:"number,omitempty"`
+
+	// Suffix is the rest of a number written as one token, upper-cased:
+	// "A" for "104A", ".5" for "104.5".
+	Suffix string `bson:"suffix,omitempty" json:"suffix,omitempty"`
+
+	// Part is N for "104 Part N" or "104 Pt. N", for episodes split over
+	// several rows that share a number; 0 otherwise.
+	Part int `bson:"part,omitempty" json:"part,omitempty"`
+
+	// Through is the last number of a row covering a range of episodes,
+	// 13 for "12–13"; 0 otherwise.
+	Through int `bson:"through,omitempty" json:"through,omitempty"`
+
+	// Kind is KindRegular, KindSpecial, KindRerun or KindCompilation.
+	Kind string `bson:"kind,omitempty" json:"kind,omitempty"`
+
+	// SortKey orders episodes by number when compared as strings: a
+	// number, then its parts, reruns, ranges and lettered suffixes.
+	// Unnumbered episodes sort after numbered ones, by their text.
+	SortKey string `bson:"sort_key,omitempty" json:"sort_key,omitempty"`
+}
+
+var (
+	episodeNoPattern = regexp.MustCompile(`^(?i:#|ep\.?|episode)?\s*(\d+)([A-Za-z]{1,2}\b|\.\d+)?\s*(.*)$`)
+
+	// throughPattern matches the end of a range such as "12–13" in the
+	// text after the first number.
+	throughPattern = regexp.MustCompile(`^(?:[-–—]|to\b)\s*#?(\d+)\b`)
+
+	// partPattern matches "Part 2", "Pt. 2" and "(Part 2)".
+	partPattern = regexp.MustCompile(`(?i)\b(?:part|pt\.?)\s*(\d+)\b`)
+
+	// kindWords are the words that mark an episode as something other than
+	// a regular episode, checked in order.
+	kindWords = []struct {
+		kind  string
+		words []string
+	}{
+		{KindRerun, []string{"rerun", "re-run", "repeat", "encore", "re-air", "reair", "replay"}},
+		{KindCompilation, []string{"best of", "compilation", "highlights", "clip show", "mixtape", "recap"}},
+		{KindSpecial, []string{"special", "bonus", "live", "holiday", "christmas", "minisode", "preview"}},
+	}
+)
+
+// ParseEpisodeNo parses the episode number column of the guide. A leading
+// number, optionally after "#", "Ep." or "Episode", with an optional
+// suffix is a regular episode unless the text around it names a kind, as
+// in "52 (Rerun)". It may be followed by the end of a range, "12–13", and
+// a part, "104 Part 2". Text without a number, such as "Special", "Best
+// Of", "1st Anniversary Special" or a blank cell, is a special unless it
+// names another kind.
+func ParseEpisodeNo(s string) Numbering {
+	text := strings.Join(strings.Fields(citationPattern.ReplaceAllString(s, "")), " ")
+	kind := episodeKind(text)
+
+	m := episodeNoPattern.FindStringSubmatch(text)
+	if m != nil && isOrdinal(m[2]) {
+		// "1st Anniversary Special" counts something else.
+		m = nil
+	}
+	if m == nil {
+		if kind == "" {
+			kind = KindSpecial
+		}
+		return Numbering{Kind: kind, SortKey: "~" + strings.ToLower(text)}
+	}
+
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		// Too many digits for an int; treat it as text.
+		return Numbering{Kind: KindSpecial, SortKey: "~" + strings.ToLower(text)}
+	}
+	if kind == "" {
+		kind = KindRegular
+	}
+	num := Numbering{Number: n, Suffix: strings.ToUpper(m[2]), Kind: kind}
+	num.SortKey = fmt.Sprintf("%06d%s", n, strings.ToLower(num.Suffix))
+	rest := m[3]
+	if t := throughPattern.FindStringSubmatch(rest); t != nil {
+		if through, err := strconv.Atoi(t[1]); err == nil && through > n {
+			num.Through = through
+			num.SortKey += fmt.Sprintf("-%06d", through)
+			rest = rest[len(t[0]):]
+		}
+	}
+	if p := partPattern.FindStringSubmatch(rest); p != nil {
+		if part, err := strconv.Atoi(p[1]); err == nil {
+			num.Part = part
+			num.SortKey += fmt.Sprintf(" part %03d", part)
+		}
+	}
+	if kind != KindRegular {
+		// After the regular episode with the same number and its parts,
+		// before its lettered parts.
+		num.SortKey += " " + kind
+	}
+	return num
+}
+
+// isOrdinal reports whether suffix makes the number before it an ordinal,
+// as in "1st".
+func isOrdinal(suffix string) bool {
+	switch strings.ToLower(suffix) {
+	case "st", "nd", "rd", "th":
+		return true
+	}
+	return false
+}
+
+func episodeKind(text string) string {
+	lower := " " + strings.ToLower(text) + " "
+	for _, k := range kindWords {
+		for _, w := range k.words {
+			if containsWord(lower, w) {
+				return k.kind
+			}
+		}
+	}
+	return ""
+}
+
+// containsWord reports whether s contains w with no letter on either side.
+func containsWord(s, w string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], w)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(w)
+		if !isLetter(s[start-1]) && (end == len(s) || !isLetter(s[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
@@ -0,0 +1,55 @@
+package scraper
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestParseEpisodeNo(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Numbering
+	}{
+		{"104", Numbering{Number: 104, Kind: KindRegular, SortKey: "000104"}},
+		{"104A", Numbering{Number: 104, Suffix: "A", Kind: KindRegular, SortKey: "000104a"}},
+		{"104b", Numbering{Number: 104, Suffix: "B", Kind: KindRegular, SortKey: "000104b"}},
+		{"104.5", Numbering{Number: 104, Suffix: ".5", Kind: KindRegular, SortKey: "000104.5"}},
+		{"#7", Numbering{Number: 7, Kind: KindRegular, SortKey: "000007"}},
+		{"Episode 12", Numbering{Number: 12, Kind: KindRegular, SortKey: "000012"}},
+		{"Ep. 52", Numbering{Number: 52, Kind: KindRegular, SortKey: "000052"}},
+		{"1st Anniversary Special", Numbering{Kind: KindSpecial, SortKey: "~1st anniversary special"}},
+		{"12[1]", Numbering{Number: 12, Kind: KindRegular, SortKey: "000012"}},
+		{"52 (Rerun)", Numbering{Number: 52, Kind: KindRerun, SortKey: "000052 rerun"}},
+		{"104 Part 1", Numbering{Number: 104, Part: 1, Kind: KindRegular, SortKey: "000104 part 001"}},
+		{"104 Pt. 2", Numbering{Number: 104, Part: 2, Kind: KindRegular, SortKey: "000104 part 002"}},
+		{"104 (Part 2) (Rerun)", Numbering{Number: 104, Part: 2, Kind: KindRerun, SortKey: "000104 part 002 rerun"}},
+		{"1-2", Numbering{Number: 1, Through: 2, Kind: KindRegular, SortKey: "000001-000002"}},
+		{"12–13", Numbering{Number: 12, Through: 13, Kind: KindRegular, SortKey: "000012-000013"}},
+		{"12 - 13 (Rerun)", Numbering{Number: 12, Through: 13, Kind: KindRerun, SortKey: "000012-000013 rerun"}},
+		{"13-12", Numbering{Number: 13, Kind: KindRegular, SortKey: "000013"}},
+		{"Special", Numbering{Kind: KindSpecial, SortKey: "~special"}},
+		{"Christmas Special", Numbering{Kind: KindSpecial, SortKey: "~christmas special"}},
+		{"Best Of", Numbering{Kind: KindCompilation, SortKey: "~best of"}},
+		{"Best of 2016 (Rerun)", Numbering{Kind: KindRerun, SortKey: "~best of 2016 (rerun)"}},
+		{"Livestream", Numbering{Kind: KindSpecial, SortKey: "~livestream"}},
+		{"", Numbering{Kind: KindSpecial, SortKey: "~"}},
+	}
+	for _, tt := range tests {
+		if got := ParseEpisodeNo(tt.in); got != tt.want {
+			t.Errorf("ParseEpisodeNo(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEpisodeNoSortKey(t *testing.T) {
+	in := []string{"Special", "104B", "9", "104 (Rerun)", "104 Part 2", "104A", "", "104", "104 Part 1", "100", "104–105"}
+	sort.Slice(in, func(i, j int) bool {
+		return ParseEpisodeNo(in[i]).SortKey < ParseEpisodeNo(in[j]).SortKey
+	})
+	want := []string{"9", "100", "104", "104 Part 1", "104 Part 2", "104 (Rerun)", "104–105", "104A", "104B", "", "Special"}
+	for i := range want {
+		if in[i] != want[i] {
+			t.Fatalf("sorted %q, want %q", in, want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+)
+
+// ParseEpisodePage parses the sections of a single episode page.
+func ParseEpisodePage(r io.Reader, url string) (TCContentSpec, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return TCContentSpec{}, fmt.Errorf("parse episode page: %w", err)
+	}
+	return parseEpisodePage(doc.Selection, url), nil
+}
+
+// CrawlEpisodePages visits the page of every episode and stores what it
+// finds in Episode.Content. Each page is fetched once, even when several
+// rows link to it, and red links to pages that don't exist are skipped.
+// Pages that fail to load are reported through opts.Warn and left without
+// content.
+func CrawlEpisodePages(ctx context.Context, episodes []Episode, opts Options) error {
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = 2
+	}
+
+	c := newCollector(ctx, opts.Transport)
+	c.Async = true
+	if err := c.Limit(&colly.LimitRule{DomainGlob: "*", Parallelism: parallelism}); err != nil {
+		return err
+	}
+
+	// Callbacks run concurrently; serialize writes to episodes and Warn.
+	var mu sync.Mutex
+	if warn := opts.Warn; warn != nil {
+		opts.Warn = func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			warn(err)
+		}
+	}
+
+	// Reruns and multi-part episodes can share a page.
+	rows := make(map[string][]int)
+	var urls []string
+	for i, e := range episodes {
+		if e.Url == "" || IsRedLink(e.Url) {
+			continue
+		}
+		if _, ok := rows[e.Url]; !ok {
+			urls = append(urls, e.Url)
+		}
+		rows[e.Url] = append(rows[e.Url], i)
+	}
+
+	c.OnResponse(func(r *colly.Response) {
+		indexes := rows[r.Ctx.Get("url")]
+		if len(indexes) == 0 {
+			return
+		}
+		first := episodes[indexes[0]]
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(r.Body))
+		if err != nil {
+			opts.warn("episode %s: parse %s: %w", first.EpisodeNo, r.Request.URL, err)
+			return
+		}
+		content := parseEpisodePage(doc.Selection, first.Url)
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, i := range indexes {
+			content := content
+			episodes[i].Content = &content
+		}
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		if indexes := rows[r.Ctx.Get("url")]; len(indexes) > 0 {
+			e := episodes[indexes[0]]
+			opts.warn("episode %s: visit %s: %w", e.EpisodeNo, e.Url, err)
+		}
+	})
+
+	for _, u := range urls {
+		cctx := colly.NewContext()
+		cctx.Put("url", u)
+		if err := c.Request("GET", u, nil, cctx, nil); err != nil {
+			opts.warn("episode %s: visit %s: %w", episodes[rows[u][0]].EpisodeNo, u, err)
+		}
+	}
+	c.Wait()
+
+	return ctx.Err()
+}
+
+// IsRedLink reports whether a wiki URL is a red link, which opens the
+// editor for a page that doesn't exist, e.g.
+// /index.php?title=Ben_Stiller&action=edit&redlink=1.
+func IsRedLink(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	q := u.Query()
+	return q.Get("redlink") == "1" || q.Get("action") == "edit"
+}
+
+// parseEpisodePage walks the article body and files each list or paragraph
+// under the section heading that precedes it.
+func parseEpisodePage(doc *goquery.Selection, url string) TCContentSpec {
+	content := TCContentSpec{
+		Url:  url,
+		Name: strings.TrimSpace(doc.Find(".mw-page-title-main").First().Text()),
+	}
+	if content.Name == "" {
+		content.Name = strings.TrimSpace(doc.Find("#firstHeading").First().Text())
+	}
+
+	var topics []string
+	section := ""
+	doc.Find(".mw-parser-output").First().Children().Each(func(_ int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "h2", "h3", "h4":
+			section = pageSection(headingText(s))
+			return
+		case "ul", "ol", "dl", "p", "blockquote", "table":
+		default:
+			return
+		}
+
+		var items []string
+		switch goquery.NodeName(s) {
+		case "ul", "ol":
+			s.ChildrenFiltered("li").Each(func(_ int, li *goquery.Selection) {
+				items = appendText(items, li)
+			})
+		case "dl":
+			s.Children().Each(func(_ int, d *goquery.Selection) {
+				items = appendText(items, d)
+			})
+		case "table":
+			s.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+				var cells []string
+				tr.Find("td").Each(func(_ int, td *goquery.Selection) {
+					cells = appendText(cells, td)
+				})
+				if len(cells) > 0 {
+					items = append(items, strings.Join(cells, " – "))
+				}
+			})
+		default:
+			items = appendText(items, s)
+		}
+
+		switch section {
+		case "guests":
+			content.Episode.Guests = append(content.Episode.Guests, items...)
+		case "topics":
+			topics = append(topics, items...)
+		case "segments":
+			content.Episode.Segments = append(content.Episode.Segments, items...)
+		case "continuity":
+			content.Episode.Continuity = append(content.Episode.Continuity, items...)
+		case "quotes":
+			content.Episode.Quotes = append(content.Episode.Quotes, items...)
+		case "topFive":
+			content.Music.TopFive = append(content.Music.TopFive, items...)
+		case "songsPlayed":
+			content.Music.SongsPlayed = append(content.Music.SongsPlayed, items...)
+		}
+	})
+	content.Episode.Topics = strings.Join(topics, "\n")
+
+	return content
+}
+
+// pageSection maps a heading on an episode page to the field it fills.
+func pageSection(heading string) string {
+	h := strings.ToLower(heading)
+	switch {
+	case strings.HasPrefix(h, "guest"):
+		return "guests"
+	case strings.HasPrefix(h, "topic"), h == "summary", h == "synopsis":
+		return "topics"
+	case strings.HasPrefix(h, "segment"):
+		return "segments"
+	case strings.HasPrefix(h, "continuity"):
+		return "continuity"
+	case strings.Contains(h, "quote"):
+		return "quotes"
+	case strings.HasPrefix(h, "top five"), strings.HasPrefix(h, "top 5"):
+		return "topFive"
+	case strings.HasPrefix(h, "songs"), h == "music", h == "playlist":
+		return "songsPlayed"
+	}
+	return ""
+}
+
+// headingText returns a heading's title without the "[edit]" link.
+func headingText(h *goquery.Selection) string {
+	if headline := h.Find(".mw-headline"); headline.Length() > 0 {
+		return strings.TrimSpace(headline.Text())
+	}
+	return cleanText(h)
+}
+
+// appendText appends the cleaned text of s to items when it is not empty.
+func appendText(items []string, s *goquery.Selection) []string {
+	if text := cleanText(s); text != "" {
+		items = append(items, text)
+	}
+	return items
+}
+
+// cleanText returns the text of s without citation markers or edit links,
+// with runs of whitespace collapsed.
+func cleanText(s *goquery.Selection) string {
+	s = s.Clone()
+	s.Find("sup.reference, .mw-editsection").Remove()
+	return strings.Join(strings.Fields(s.Text()), " ")
+}
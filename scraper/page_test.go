@@ -0,0 +1,125 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestParseEpisodePage(t *testing.T) {
+	f, err := os.Open("testdata/episode_page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := ParseEpisodePage(f, "https://example.com/wiki/Who_Is_the_Rolling_Stones%3F")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := TCContentSpec{
+		Url:  "https://example.com/wiki/Who_Is_the_Rolling_Stones%3F",
+		Name: "Who Is the Rolling Stones?",
+		Episode: TCEpisodeSpec{
+			Guests:     []string{"Jake Longstreth", "Jonah Hill (by phone)"},
+			Topics:     "The Stones' late-70s output.\nWhether Some Girls is a disco album.",
+			Segments:   []string{"Top Five", "Where's Jake?"},
+			Continuity: []string{"First appearance of Jonah Hill."},
+			Quotes:     []string{`"Some Girls is a perfect album." – Ezra`},
+		},
+		Music: TCMusicSpec{
+			TopFive: []string{
+				`"Miss You" – The Rolling Stones`,
+				`"Beast of Burden" – The Rolling Stones`,
+				`"Shattered" – The Rolling Stones`,
+				`"Respectable" – The Rolling Stones`,
+				`"Far Away Eyes" – The Rolling Stones`,
+			},
+			SongsPlayed: []string{`"Miss You" – The Rolling Stones`, `"Hotel California" – Eagles`},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseEpisodePage =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestCrawlEpisodePages(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/wiki/Found", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/episode_page.html")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	episodes := []Episode{
+		{EpisodeNo: "4", Url: srv.URL + "/wiki/Found"},
+		{EpisodeNo: "5", Url: srv.URL + "/wiki/Missing"},
+	}
+
+	var warnings []error
+	err := CrawlEpisodePages(context.Background(), episodes, Options{
+		Warn: func(err error) { warnings = append(warnings, err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if episodes[0].Content == nil || len(episodes[0].Content.Music.TopFive) != 5 {
+		t.Errorf("episode 4 content = %+v, want parsed page", episodes[0].Content)
+	}
+	if episodes[1].Content != nil {
+		t.Errorf("episode 5 content = %+v, want nil", episodes[1].Content)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("got %d warnings, want 1: %v", len(warnings), warnings)
+	}
+}
+
+func TestCrawlEpisodePagesFetchesEachPageOnce(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		http.ServeFile(w, r, "testdata/episode_page.html")
+	}))
+	defer srv.Close()
+
+	episodes := []Episode{
+		{EpisodeNo: "104 Part 1", Url: srv.URL + "/wiki/Two_Parter"},
+		{EpisodeNo: "104 Part 2", Url: srv.URL + "/wiki/Two_Parter"},
+		{EpisodeNo: "105", Url: srv.URL + "/index.php?title=Not_Written&action=edit&redlink=1"},
+	}
+
+	var warnings []error
+	err := CrawlEpisodePages(context.Background(), episodes, Options{
+		Warn: func(err error) { warnings = append(warnings, err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]int{"/wiki/Two_Parter": 1}; !reflect.DeepEqual(hits, want) {
+		t.Errorf("requests = %v, want %v", hits, want)
+	}
+	for _, e := range episodes[:2] {
+		if e.Content == nil || len(e.Content.Music.TopFive) != 5 {
+			t.Errorf("episode %s content = %+v, want parsed page", e.EpisodeNo, e.Content)
+		}
+	}
+	if episodes[0].Content == episodes[1].Content {
+		t.Error("episodes sharing a page share a Content pointer")
+	}
+	if episodes[2].Content != nil {
+		t.Errorf("red link content = %+v, want nil", episodes[2].Content)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("got warnings: %v", warnings)
+	}
+}
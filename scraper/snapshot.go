@@ -0,0 +1,182 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gocolly/colly"
+)
+
+// SnapshotPath returns where a page is stored inside a snapshot directory:
+// <host>/<escaped path>.html, e.g.
+// the-time-crisis-universe.fandom.com/wiki/Episode_Guide.html. A URL with
+// a query gets a hash of it before the extension, so that
+// /index.php?title=A and /index.php?title=B are different files.
+func SnapshotPath(u *url.URL) string {
+	p := strings.TrimSuffix(path.Clean("/"+u.EscapedPath()), "/")
+	if p == "" {
+		p = "/index"
+	}
+	if u.RawQuery != "" {
+		// Encode sorts the parameters, so their order doesn't matter.
+		sum := sha256.Sum256([]byte(u.Query().Encode()))
+		p += "~" + hex.EncodeToString(sum[:4])
+	}
+	return filepath.Join(u.Host, filepath.FromSlash(p)+".html")
+}
+
+// DirTransport serves GET requests from a snapshot directory written by
+// SaveSnapshot instead of the network. Pages missing from the snapshot get
+// a 404. Use it as Options.Transport to scrape offline.
+func DirTransport(dir string) http.RoundTripper {
+	return dirTransport{dir: dir}
+}
+
+type dirTransport struct {
+	dir string
+}
+
+func (t dirTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return response(req, http.StatusMethodNotAllowed, nil), nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(t.dir, SnapshotPath(req.URL)))
+	if os.IsNotExist(err) {
+		return response(req, http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response(req, http.StatusOK, data), nil
+}
+
+func response(req *http.Request, status int, body []byte) *http.Response {
+	header := http.Header{}
+	if body != nil {
+		header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// SaveSnapshot downloads the Episode Guide and every episode page it links
+// to, except red links, into dir, laid out for DirTransport. It returns the number of pages
+// written. Episode pages that fail are reported through opts.Warn.
+func SaveSnapshot(ctx context.Context, dir string, opts Options) (int, error) {
+	if opts.URL == "" {
+		opts.URL = EpisodeGuideURL
+	}
+
+	var guide []byte
+	c := newCollector(ctx, opts.Transport)
+	c.OnResponse(func(r *colly.Response) {
+		guide = r.Body
+	})
+	if err := c.Visit(opts.URL); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, ctxErr
+		}
+		return 0, fmt.Errorf("visit %s: %w", opts.URL, err)
+	}
+
+	guideURL, err := url.Parse(opts.URL)
+	if err != nil {
+		return 0, err
+	}
+	if err := writeSnapshot(dir, guideURL, guide); err != nil {
+		return 0, err
+	}
+	written := 1
+
+	episodes, err := ScrapeEpisodeGuide(ctx, Options{Reader: bytes.NewReader(guide), URL: opts.URL, Warn: opts.Warn})
+	if err != nil {
+		return written, err
+	}
+
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = 2
+	}
+	pages := newCollector(ctx, opts.Transport)
+	pages.Async = true
+	if err := pages.Limit(&colly.LimitRule{DomainGlob: "*", Parallelism: parallelism}); err != nil {
+		return written, err
+	}
+
+	var mu sync.Mutex
+	var writeErr error
+	pages.OnResponse(func(r *colly.Response) {
+		// Save under the URL we asked for, not where a redirect ended up,
+		// so DirTransport finds it under the same name.
+		u, err := url.Parse(r.Ctx.Get("url"))
+		if err == nil {
+			err = writeSnapshot(dir, u, r.Body)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil && writeErr == nil {
+			writeErr = err
+		}
+		if err == nil {
+			written++
+		}
+	})
+	pages.OnError(func(r *colly.Response, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		opts.warn("visit %s: %w", r.Request.URL, err)
+	})
+
+	visited := make(map[string]bool)
+	for _, e := range episodes {
+		if e.Url == "" || IsRedLink(e.Url) || visited[e.Url] {
+			continue
+		}
+		visited[e.Url] = true
+		cctx := colly.NewContext()
+		cctx.Put("url", e.Url)
+		if err := pages.Request("GET", e.Url, nil, cctx, nil); err != nil {
+			mu.Lock()
+			opts.warn("episode %s: visit %s: %w", e.EpisodeNo, e.Url, err)
+			mu.Unlock()
+		}
+	}
+	pages.Wait()
+
+	if writeErr != nil {
+		return written, writeErr
+	}
+	return written, ctx.Err()
+}
+
+func writeSnapshot(dir string, u *url.URL, body []byte) error {
+	name := filepath.Join(dir, SnapshotPath(u))
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, body, 0o644)
+}
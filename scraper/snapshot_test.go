@@ -0,0 +1,88 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wiki/Episode_Guide":
+			http.ServeFile(w, r, "testdata/episode_guide.html")
+		case "/wiki/Who_Is_the_Rolling_Stones?":
+			http.ServeFile(w, r, "testdata/episode_page.html")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	guideURL := srv.URL + "/wiki/Episode_Guide"
+
+	live, err := ScrapeEpisodeGuide(context.Background(), Options{URL: guideURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CrawlEpisodePages(context.Background(), live, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	written, err := SaveSnapshot(context.Background(), dir, Options{URL: guideURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	// The guide and the one episode page the server knows about.
+	if written != 2 {
+		t.Errorf("wrote %d pages, want 2", written)
+	}
+
+	offline, err := ScrapeEpisodeGuide(context.Background(), Options{URL: guideURL, Transport: DirTransport(dir)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CrawlEpisodePages(context.Background(), offline, Options{Transport: DirTransport(dir)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if live[3].Content == nil {
+		t.Fatalf("episode %s has no page content", live[3].EpisodeNo)
+	}
+	if !reflect.DeepEqual(live, offline) {
+		t.Errorf("offline scrape differs from live scrape:\nlive    %+v\noffline %+v", live, offline)
+	}
+}
+
+func TestSnapshotPath(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://example.com/wiki/Episode_Guide", "example.com/wiki/Episode_Guide.html"},
+		{"https://example.com/wiki/Who_Is_the_Rolling_Stones%3F", "example.com/wiki/Who_Is_the_Rolling_Stones%3F.html"},
+		{"https://example.com/", "example.com/index.html"},
+		{"https://example.com/wiki/../etc/passwd", "example.com/etc/passwd.html"},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.in)
+		if got := SnapshotPath(u); got != filepath.FromSlash(tt.want) {
+			t.Errorf("SnapshotPath(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	path := func(s string) string {
+		u, _ := url.Parse(s)
+		return SnapshotPath(u)
+	}
+	a := path("https://example.com/index.php?title=A&action=edit&redlink=1")
+	b := path("https://example.com/index.php?title=B&action=edit&redlink=1")
+	if a == b || a == path("https://example.com/index.php") {
+		t.Errorf("URLs with different queries share %s", a)
+	}
+	if reordered := path("https://example.com/index.php?redlink=1&action=edit&title=A"); reordered != a {
+		t.Errorf("reordered query: %s, want %s", reordered, a)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"webscraper/scraper"
+	"webscraper/search"
+	"webscraper/store"
+)
+
+func runSearch(ctx context.Context, args []string) error {
+	fs := newFlagSet("search", "<query>", `
+Print the stored episodes that best match the query.
+
+The default -mode hybrid fuses a BM25 keyword ranking over title, notes,
+guests and episode number with embedding similarity, so guest names and
+episode numbers match exactly while paraphrases still work. -mode vector
+ranks by embedding alone and -mode keyword by text alone (no embedding
+API call).
+
+The query is embedded with the configured embedding model. MongoDB uses
+the Atlas $vectorSearch stage when the -vector-index exists and a text
+index for keywords; other stores rank in process.`)
+	storeCfg := addStoreFlags(fs)
+	embedderCfg := addEmbedderFlags(fs)
+	retryPolicy := addRetryFlags(fs)
+	limit := fs.Int("limit", 5, "number of episodes to print")
+	filter := addFilterFlags(fs)
+	mode := fs.String("mode", "hybrid", "ranking: hybrid, vector or keyword")
+	fusion := fs.String("fusion", string(search.RRF), "how -mode hybrid combines rankings: rrf (reciprocal rank) or weighted (normalised scores)")
+	keywordWeight := fs.Float64("keyword-weight", 1, "weight of the keyword ranking in -mode hybrid")
+	vectorWeight := fs.Float64("vector-weight", 1, "weight of the vector ranking in -mode hybrid")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	query := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if query == "" {
+		fs.Usage()
+		return fmt.Errorf("missing query")
+	}
+	if *mode != "hybrid" && *mode != "vector" && *mode != "keyword" {
+		return fmt.Errorf("unknown -mode %q (want hybrid, vector or keyword)", *mode)
+	}
+
+	episodeStore, err := storeCfg.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer episodeStore.Close(context.Background())
+
+	opts := store.SearchOptions{Limit: *limit, Filter: *filter}
+	var matches []store.Match
+	if *mode == "keyword" {
+		matches, err = search.Keyword(ctx, episodeStore, query, opts, nil)
+	} else {
+		embedder, embedErr := embedderCfg.new(retryPolicy)
+		if embedErr != nil {
+			return embedErr
+		}
+		if *mode == "vector" {
+			matches, err = search.Semantic(ctx, episodeStore, embedder, query, opts)
+		} else {
+			matches, err = search.Hybrid(ctx, episodeStore, embedder, query, search.HybridOptions{
+				SearchOptions: opts,
+				Fusion:        search.Fusion(*fusion),
+				KeywordWeight: *keywordWeight,
+				VectorWeight:  *vectorWeight,
+			})
+		}
+	}
+	if err != nil {
+		return err
+	}
+	printMatches(matches)
+	return nil
+}
+
+// printMatches prints ranked search results.
+func printMatches(matches []store.Match) {
+	if len(matches) == 0 {
+		fmt.Println("No matching episodes.")
+		return
+	}
+	for i, m := range matches {
+		e := m.Episode
+		fmt.Printf("%d. [%.3f] #%s %s (%s)\n", i+1, m.Score, e.EpisodeNo, e.Title, e.Date)
+		if len(e.Guests) > 0 {
+			fmt.Printf("   Guests: %s\n", strings.Join(e.Guests, ", "))
+		}
+		fmt.Printf("   %s\n", e.Url)
+	}
+}
+
+// addFilterFlags registers -from, -to, -guest, -episodes and -kind, which
+// fill in the returned filter as they are parsed.
+func addFilterFlags(fs *flag.FlagSet) *store.Filter {
+	f := &store.Filter{}
+	fs.Func("from", "only episodes on or after this date (YYYY-MM-DD)", dateFlag(&f.From))
+	fs.Func("to", "only episodes on or before this date (YYYY-MM-DD)", dateFlag(&f.To))
+	fs.StringVar(&f.Guest, "guest", "", "only episodes with a guest whose name contains this, ignoring case")
+	fs.Func("episodes", "only episodes numbered in this range, e.g. 100-150 or 100-", func(s string) error {
+		var err error
+		f.NumberFrom, f.NumberTo, err = store.ParseNumberRange(s)
+		return err
+	})
+	fs.Func("kind", "only episodes of this kind: "+strings.Join(scraper.Kinds, ", "), func(s string) error {
+		f.Kind = s
+		return store.CheckKind(s)
+	})
+	return f
+}
+
+func dateFlag(t *time.Time) func(string) error {
+	return func(s string) error {
+		parsed, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return fmt.Errorf("want YYYY-MM-DD")
+		}
+		*t = parsed
+		return nil
+	}
+}
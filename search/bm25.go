@@ -0,0 +1,109 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"webscraper/store"
+)
+
+// BM25 parameters: k1 controls term-frequency saturation and b how much
+// longer documents are penalised.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Index is an in-memory BM25 full-text index over episode titles, notes,
+// guests and episode numbers. It is used for keyword search against stores
+// without a native text index.
+type Index struct {
+	episodes []store.Episode
+	terms    []map[string]int // term frequencies per episode
+	lengths  []int
+	avgLen   float64
+	docFreq  map[string]int
+}
+
+// NewIndex indexes episodes.
+func NewIndex(episodes []store.Episode) *Index {
+	idx := &Index{
+		episodes: episodes,
+		terms:    make([]map[string]int, len(episodes)),
+		lengths:  make([]int, len(episodes)),
+		docFreq:  make(map[string]int),
+	}
+	total := 0
+	for i, e := range episodes {
+		tf := make(map[string]int)
+		tokens := tokenize(indexText(e))
+		for _, t := range tokens {
+			tf[t]++
+		}
+		for t := range tf {
+			idx.docFreq[t]++
+		}
+		idx.terms[i] = tf
+		idx.lengths[i] = len(tokens)
+		total += len(tokens)
+	}
+	if len(episodes) > 0 {
+		idx.avgLen = float64(total) / float64(len(episodes))
+	}
+	return idx
+}
+
+// Search returns the episodes matching any term of query, best BM25 score
+// first, restricted by opts.Filter and cut to opts.Limit.
+func (idx *Index) Search(query string, opts store.SearchOptions) []store.Match {
+	terms := uniqueTokens(query)
+	n := float64(len(idx.episodes))
+	var matches []store.Match
+	for i, e := range idx.episodes {
+		score := 0.0
+		for _, t := range terms {
+			tf := float64(idx.terms[i][t])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[t])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(idx.lengths[i])/idx.avgLen)
+			score += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+		if score > 0 && opts.Filter.Match(e) {
+			matches = append(matches, store.Match{Episode: e, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if opts.Limit > 0 && len(matches) > opts.Limit {
+		matches = matches[:opts.Limit]
+	}
+	return matches
+}
+
+// indexText is the text an episode is found by.
+func indexText(e store.Episode) string {
+	return strings.Join([]string{e.EpisodeNo, e.Title, strings.Join(e.Guests, " "), e.Notes}, " ")
+}
+
+// tokenize lowercases s and splits it into runs of letters and digits.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func uniqueTokens(s string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, t := range tokenize(s) {
+		if !seen[t] {
+			seen[t] = true
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,171 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"webscraper/embedding"
+	"webscraper/store"
+)
+
+// Fusion is how Hybrid combines the keyword and vector rankings.
+type Fusion string
+
+const (
+	// RRF is reciprocal-rank fusion: each list contributes
+	// weight/(RRFK+rank) for every episode in it. Only ranks matter, so the
+	// very different score scales of BM25 and cosine similarity don't.
+	RRF Fusion = "rrf"
+
+	// Weighted rescales each list's scores to 0..1 and adds them,
+	// multiplied by their weights.
+	Weighted Fusion = "weighted"
+)
+
+// RRFK dampens the advantage of the very top ranks in RRF. 60 is the value
+// from the original paper.
+const RRFK = 60
+
+// HybridOptions configures Hybrid.
+type HybridOptions struct {
+	store.SearchOptions
+
+	// Fusion defaults to RRF.
+	Fusion Fusion
+
+	// KeywordWeight and VectorWeight scale each ranking. Both default to 1
+	// when both are zero.
+	KeywordWeight float64
+	VectorWeight  float64
+
+	// Index is searched for keywords when the store has no text index of
+	// its own. If nil, one is built from every stored episode.
+	Index *Index
+}
+
+// Hybrid ranks stored episodes by both full-text relevance to query and
+// embedding similarity, so exact names and episode numbers match as well
+// as paraphrases. Match.Score is the fused score.
+func Hybrid(ctx context.Context, s store.EpisodeStore, embedder embedding.Embedder, query string, opts HybridOptions) ([]store.Match, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	// Fuse deeper lists than we return, so an episode ranked modestly by
+	// both can still come out on top.
+	deep := opts.SearchOptions
+	deep.Limit = limit * 4
+
+	vector, err := Semantic(ctx, s, embedder, query, deep)
+	if err != nil {
+		return nil, err
+	}
+	keyword, err := Keyword(ctx, s, query, deep, opts.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	kw, vw := opts.KeywordWeight, opts.VectorWeight
+	if kw == 0 && vw == 0 {
+		kw, vw = 1, 1
+	}
+	var fused []store.Match
+	switch opts.Fusion {
+	case RRF, "":
+		fused = fuse(func(list []store.Match, i int) float64 { return 1 / float64(RRFK+i+1) },
+			weighted{keyword, kw}, weighted{vector, vw})
+	case Weighted:
+		fused = fuse(minMax, weighted{keyword, kw}, weighted{vector, vw})
+	default:
+		return nil, fmt.Errorf("unknown fusion %q (want rrf or weighted)", opts.Fusion)
+	}
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused, nil
+}
+
+// Keyword returns the episodes that best match the words of query. It uses
+// the store's text index when it has one and otherwise index, building it
+// from every stored episode if nil. A failing text index, such as one that
+// could not be created, falls back to building index too.
+func Keyword(ctx context.Context, s store.EpisodeStore, query string, opts store.SearchOptions, index *Index) ([]store.Match, error) {
+	if ts, ok := s.(store.TextSearcher); ok && index == nil {
+		matches, err := ts.TextSearch(ctx, query, opts)
+		if err == nil || ctx.Err() != nil {
+			return matches, err
+		}
+	}
+	if index == nil {
+		var err error
+		if index, err = BuildIndex(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+	return index.Search(query, opts), nil
+}
+
+// BuildIndex indexes every stored episode.
+func BuildIndex(ctx context.Context, s store.EpisodeStore) (*Index, error) {
+	var episodes []store.Episode
+	err := store.ForEach(ctx, s, func(e store.Episode) error {
+		e.Embedding = nil // not needed for keyword search
+		episodes = append(episodes, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewIndex(episodes), nil
+}
+
+type weighted struct {
+	matches []store.Match
+	weight  float64
+}
+
+// fuse sums weight*score(list, i) per episode over the lists and sorts by
+// the total, breaking ties by ID.
+func fuse(score func(list []store.Match, i int) float64, lists ...weighted) []store.Match {
+	byID := make(map[string]*store.Match)
+	var order []*store.Match
+	for _, l := range lists {
+		for i, m := range l.matches {
+			f, ok := byID[m.Episode.ID]
+			if !ok {
+				f = &store.Match{Episode: m.Episode}
+				byID[m.Episode.ID] = f
+				order = append(order, f)
+			}
+			f.Score += l.weight * score(l.matches, i)
+		}
+	}
+	fused := make([]store.Match, len(order))
+	for i, f := range order {
+		fused[i] = *f
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		if fused[i].Score != fused[j].Score {
+			return fused[i].Score > fused[j].Score
+		}
+		return fused[i].Episode.ID < fused[j].Episode.ID
+	})
+	return fused
+}
+
+// minMax rescales the score of list[i] to 0..1 within the list. A list
+// whose scores are all equal scores 1.
+func minMax(list []store.Match, i int) float64 {
+	lo, hi := list[0].Score, list[0].Score
+	for _, m := range list {
+		lo, hi = min(lo, m.Score), max(hi, m.Score)
+	}
+	if hi == lo {
+		return 1
+	}
+	return (list[i].Score - lo) / (hi - lo)
+}
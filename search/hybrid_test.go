@@ -0,0 +1,101 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+	"webscraper/store"
+)
+
+func TestIndexRanksRareTermsHigher(t *testing.T) {
+	idx := NewIndex([]store.Episode{
+		{Episode: scraper.Episode{ID: "a", EpisodeNo: "1", Title: "Rock and more rock", Guests: []string{"Jake Longstreth"}}},
+		{Episode: scraper.Episode{ID: "b", EpisodeNo: "2", Title: "Rock", Guests: []string{"Seth Rogen"}}},
+		{Episode: scraper.Episode{ID: "c", EpisodeNo: "21", Title: "Jazz", Notes: "No rock at all"}},
+	})
+
+	got := idx.Search("Seth Rogen rock", store.SearchOptions{})
+	if len(got) != 3 || got[0].Episode.ID != "b" {
+		t.Errorf("got %+v, want b first", got)
+	}
+	if got := idx.Search("21", store.SearchOptions{}); len(got) != 1 || got[0].Episode.ID != "c" {
+		t.Errorf("episode number: got %+v", got)
+	}
+	if got := idx.Search("rock", store.SearchOptions{Filter: store.Filter{Guest: "jake"}}); len(got) != 1 || got[0].Episode.ID != "a" {
+		t.Errorf("filtered: got %+v", got)
+	}
+	if got := idx.Search("polka", store.SearchOptions{}); len(got) != 0 {
+		t.Errorf("unknown term matched %+v", got)
+	}
+}
+
+func TestHybrid(t *testing.T) {
+	ctx := context.Background()
+	embedder := embedding.NewFake(64)
+	s := seed(t, embedder)
+
+	for _, fusion := range []Fusion{RRF, Weighted} {
+		t.Run(string(fusion), func(t *testing.T) {
+			// "Longstreth" only appears in guests, which are not embedded
+			// in seed, so only the keyword side finds it.
+			matches, err := Hybrid(ctx, s, embedder, "Longstreth", HybridOptions{
+				SearchOptions: store.SearchOptions{Limit: 2},
+				Fusion:        fusion,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(matches) != 2 {
+				t.Fatalf("got %d matches, want 2", len(matches))
+			}
+			for _, m := range matches {
+				if m.Episode.ID == "2" {
+					t.Errorf("episode without the guest ranked in the top 2: %+v", matches)
+				}
+			}
+		})
+	}
+
+	// With the keyword side switched off, hybrid ranks like Semantic.
+	semantic, err := Semantic(ctx, s, embedder, "yacht rock", store.SearchOptions{Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hybrid, err := Hybrid(ctx, s, embedder, "yacht rock", HybridOptions{
+		SearchOptions: store.SearchOptions{Limit: 3},
+		VectorWeight:  1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range semantic {
+		if semantic[i].Episode.ID != hybrid[i].Episode.ID {
+			t.Errorf("rank %d: semantic %s, hybrid %s", i, semantic[i].Episode.ID, hybrid[i].Episode.ID)
+		}
+	}
+
+	if _, err := Hybrid(ctx, s, embedder, "x", HybridOptions{Fusion: "max"}); err == nil {
+		t.Error("unknown fusion accepted")
+	}
+}
+
+// brokenTextIndex is a store whose text index can't be created.
+type brokenTextIndex struct{ store.EpisodeStore }
+
+func (brokenTextIndex) TextSearch(context.Context, string, store.SearchOptions) ([]store.Match, error) {
+	return nil, errors.New("create text index: not authorized")
+}
+
+func TestKeywordFallsBackWhenTextSearchFails(t *testing.T) {
+	s := brokenTextIndex{seed(t, embedding.NewFake(64))}
+	matches, err := Keyword(context.Background(), s, "Longstreth", store.SearchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) == 0 {
+		t.Error("got no matches from the in-memory index")
+	}
+}
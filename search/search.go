@@ -0,0 +1,27 @@
+// Package search finds stored episodes that match a free-text query.
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"webscraper/embedding"
+	"webscraper/store"
+)
+
+// Semantic embeds query with embedder and returns the stored episodes
+// nearest to it, best first. Only vectors from the embedder's model are
+// compared, so the store must have been embedded with the same model.
+func Semantic(ctx context.Context, s store.EpisodeStore, embedder embedding.Embedder, query string, opts store.SearchOptions) ([]store.Match, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+	vector, err := embedding.One(ctx, embedder, query)
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	opts.Model = embedder.Model()
+	return s.VectorSearch(ctx, vector, opts)
+}
@@ -0,0 +1,80 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+	"webscraper/store"
+	"webscraper/store/storetest"
+)
+
+func seed(t *testing.T, embedder embedding.Embedder) store.EpisodeStore {
+	t.Helper()
+	episodes := []store.Episode{
+		{Episode: scraper.Episode{ID: "1", Title: "Yacht rock summit", Guests: []string{"Jake Longstreth"},
+			Timestamp: time.Date(2015, 4, 19, 0, 0, 0, 0, time.UTC)}},
+		{Episode: scraper.Episode{ID: "2", Title: "Yacht rock revisited", Guests: []string{"Jonah Hill"},
+			Timestamp: time.Date(2016, 1, 10, 0, 0, 0, 0, time.UTC)}},
+		{Episode: scraper.Episode{ID: "3", Title: "Corporate rock countdown", Guests: []string{"Jake Longstreth"}}},
+	}
+	return storetest.Seed(t, embedder, episodes)
+}
+
+func TestSemantic(t *testing.T) {
+	ctx := context.Background()
+	embedder := embedding.NewFake(64)
+	s := seed(t, embedder)
+
+	matches, err := Semantic(ctx, s, embedder, "yacht rock", store.SearchOptions{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 || matches[0].Episode.ID == "3" || matches[1].Episode.ID == "3" {
+		t.Errorf("got %+v, want the two yacht rock episodes", matches)
+	}
+
+	tests := []struct {
+		name   string
+		filter store.Filter
+		want   []string
+	}{
+		{"guest", store.Filter{Guest: "longstreth"}, []string{"1", "3"}},
+		{"from", store.Filter{From: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)}, []string{"2"}},
+		{"to", store.Filter{To: time.Date(2015, 12, 31, 0, 0, 0, 0, time.UTC)}, []string{"1"}},
+		{"guest and date", store.Filter{Guest: "jonah", To: time.Date(2015, 12, 31, 0, 0, 0, 0, time.UTC)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := Semantic(ctx, s, embedder, "rock", store.SearchOptions{Filter: tt.filter})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]bool{}
+			for _, m := range matches {
+				got[m.Episode.ID] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSemanticSkipsOtherModels(t *testing.T) {
+	s := seed(t, embedding.NewFake(64))
+	matches, err := Semantic(context.Background(), s, embedding.NewFake(32), "yacht rock", store.SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("matched %d episodes embedded with another model", len(matches))
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"webscraper/api"
+)
+
+func runServe(ctx context.Context, args []string) error {
+	fs := newFlagSet("serve", "", `
+Serve the stored episodes as a JSON HTTP API:
+
+  GET  /episodes        ?limit, ?cursor, ?year, ?guest, ?q, ?episodes, ?kind
+  GET  /episodes/{id}
+  GET  /guests
+  POST /search          {"query", "mode", "limit", "from", "to", "guest", "episodes", "kind"}
+
+Search embeds the query with the configured embedder, which must match the
+model the episodes were embedded with. On interrupt the server stops
+accepting connections and waits for requests in flight to finish.`)
+	storeCfg := addStoreFlags(fs)
+	embedderCfg := addEmbedderFlags(fs)
+	retryPolicy := addRetryFlags(fs)
+	addr := fs.String("addr", envOr("TC_ADDR", ":8080"), "listen address (env TC_ADDR)")
+	shutdownTimeout := fs.Duration("shutdown-timeout", 10*time.Second, "how long to wait for requests in flight on shutdown")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	episodeStore, err := storeCfg.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer episodeStore.Close(context.Background())
+
+	embedder, err := embedderCfg.new(retryPolicy)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           api.NewHandler(episodeStore, embedder),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	fmt.Printf("✅ Listening on %s\n", *addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
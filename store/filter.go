@@ -0,0 +1,124 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"webscraper/scraper"
+)
+
+// Filter narrows the episodes a query returns. The zero Filter matches
+// everything.
+type Filter struct {
+	// From and To bound the episode's Timestamp, both inclusive. Episodes
+	// without a parsed date never match a date bound.
+	From, To time.Time
+
+	// Guest matches episodes with a guest whose name contains it, ignoring
+	// case.
+	Guest string
+
+	// Text matches episodes whose title, notes or guests contain it,
+	// ignoring case.
+	Text string
+
+	// NumberFrom and NumberTo bound Numbering.Number, both inclusive; zero
+	// means unbounded. Episodes without a number never match a number
+	// bound.
+	NumberFrom, NumberTo int
+
+	// Kind matches episodes whose Numbering.Kind equals it, such as
+	// scraper.KindRegular.
+	Kind string
+}
+
+// Match reports whether e passes the filter.
+func (f Filter) Match(e Episode) bool {
+	if !f.From.IsZero() || !f.To.IsZero() {
+		if e.Timestamp.IsZero() {
+			return false
+		}
+		if !f.From.IsZero() && e.Timestamp.Before(f.From) {
+			return false
+		}
+		if !f.To.IsZero() && e.Timestamp.After(f.To) {
+			return false
+		}
+	}
+	if f.Guest != "" && !hasGuest(e, f.Guest) {
+		return false
+	}
+	if f.Text != "" && !hasText(e, f.Text) {
+		return false
+	}
+	if f.NumberFrom != 0 || f.NumberTo != 0 {
+		n := e.Numbering.Number
+		if n == 0 || (f.NumberFrom != 0 && n < f.NumberFrom) || (f.NumberTo != 0 && n > f.NumberTo) {
+			return false
+		}
+	}
+	if f.Kind != "" && e.Numbering.Kind != f.Kind {
+		return false
+	}
+	return true
+}
+
+// ParseNumberRange parses an episode number range for Filter.NumberFrom
+// and NumberTo: "100-150" (or with an en dash), an open range such as
+// "100-" or "-150", or a single number.
+func ParseNumberRange(s string) (from, to int, err error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "–", "-")
+	lo, hi, isRange := strings.Cut(s, "-")
+	if !isRange {
+		hi = lo
+	}
+	parse := func(v string) (int, error) {
+		v = strings.TrimSpace(v)
+		if v == "" && isRange {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid episode range %q (want e.g. 100-150)", s)
+		}
+		return n, nil
+	}
+	if from, err = parse(lo); err != nil {
+		return 0, 0, err
+	}
+	if to, err = parse(hi); err != nil {
+		return 0, 0, err
+	}
+	if (from == 0 && to == 0) || (to != 0 && from > to) {
+		return 0, 0, fmt.Errorf("invalid episode range %q (want e.g. 100-150)", s)
+	}
+	return from, to, nil
+}
+
+// CheckKind returns an error unless kind is empty or one of scraper.Kinds.
+func CheckKind(kind string) error {
+	if kind != "" && !slices.Contains(scraper.Kinds, kind) {
+		return fmt.Errorf("unknown episode kind %q (want %s)", kind, strings.Join(scraper.Kinds, ", "))
+	}
+	return nil
+}
+
+func hasText(e Episode, text string) bool {
+	text = strings.ToLower(text)
+	return strings.Contains(strings.ToLower(e.Title), text) ||
+		strings.Contains(strings.ToLower(e.Notes), text) ||
+		hasGuest(e, text)
+}
+
+func hasGuest(e Episode, guest string) bool {
+	guest = strings.ToLower(guest)
+	for _, g := range e.Guests {
+		if strings.Contains(strings.ToLower(g), guest) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package store
+
+import "testing"
+
+func TestParseNumberRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		from, to int
+	}{
+		{"100-150", 100, 150},
+		{"100–150", 100, 150},
+		{" 100 - 150 ", 100, 150},
+		{"100-", 100, 0},
+		{"-150", 0, 150},
+		{"104", 104, 104},
+	}
+	for _, tt := range tests {
+		from, to, err := ParseNumberRange(tt.in)
+		if err != nil || from != tt.from || to != tt.to {
+			t.Errorf("ParseNumberRange(%q) = %d, %d, %v; want %d, %d", tt.in, from, to, err, tt.from, tt.to)
+		}
+	}
+	for _, in := range []string{"", "-", "150-100", "0", "ten", "1-2-3"} {
+		if from, to, err := ParseNumberRange(in); err == nil {
+			t.Errorf("ParseNumberRange(%q) = %d, %d; want an error", in, from, to)
+		}
+	}
+}
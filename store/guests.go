@@ -0,0 +1,137 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"webscraper/scraper"
+)
+
+// MaxCoGuests is how many frequent co-guests a Guest record keeps.
+const MaxCoGuests = 10
+
+// Guest is a derived record summarizing one person's appearances. The
+// records are rebuilt from the episodes by BuildGuests after every sync.
+type Guest struct {
+	ID   string `bson:"_id" json:"id"`
+	Name string `bson:"name" json:"name"`
+	URL  string `bson:"url,omitempty" json:"url,omitempty"`
+
+	Appearances int        `bson:"appearances" json:"appearances"`
+	First       Appearance `bson:"first" json:"first"`
+	Last        Appearance `bson:"last" json:"last"`
+	EpisodeIDs  []string   `bson:"episode_ids" json:"episode_ids"` // oldest first
+
+	// CoGuests are the guests who most often appeared in the same
+	// episode, most frequent first.
+	CoGuests []CoGuest `bson:"co_guests,omitempty" json:"co_guests,omitempty"`
+}
+
+// Appearance identifies the episode a guest appeared in.
+type Appearance struct {
+	EpisodeID string    `bson:"episode_id" json:"episode_id"`
+	EpisodeNo string    `bson:"episode_no" json:"episode_no"`
+	Title     string    `bson:"title" json:"title"`
+	Date      string    `bson:"date" json:"date"`
+	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
+}
+
+// CoGuest counts the episodes two guests shared.
+type CoGuest struct {
+	ID       string `bson:"id" json:"id"`
+	Name     string `bson:"name" json:"name"`
+	Episodes int    `bson:"episodes" json:"episodes"`
+}
+
+// EpisodeGuests returns the normalized guests of e. Episodes stored before
+// guests were normalized only have names, so their IDs are derived here.
+func EpisodeGuests(e Episode) []scraper.Guest {
+	if e.GuestRefs != nil {
+		return e.GuestRefs
+	}
+	refs := make([]scraper.Guest, 0, len(e.Guests))
+	for _, name := range e.Guests {
+		if id := scraper.GuestID(name); id != "" {
+			refs = append(refs, scraper.Guest{ID: id, Name: name})
+		}
+	}
+	return refs
+}
+
+// BuildGuests derives one Guest record per person from the episodes,
+// ordered by appearances, most first, then by name.
+func BuildGuests(episodes []Episode) []Guest {
+	episodes = append([]Episode(nil), episodes...)
+	sort.SliceStable(episodes, func(i, j int) bool { return AppearsBefore(episodes[i], episodes[j]) })
+
+	byID := make(map[string]*Guest)
+	together := make(map[string]map[string]int) // guest ID → co-guest ID → episodes
+	for _, e := range episodes {
+		refs := EpisodeGuests(e)
+		appearance := Appearance{EpisodeID: e.ID, EpisodeNo: e.EpisodeNo, Title: e.Title, Date: e.Date, Timestamp: e.Timestamp}
+		for _, ref := range refs {
+			g, ok := byID[ref.ID]
+			if !ok {
+				g = &Guest{ID: ref.ID, Name: ref.Name, First: appearance}
+				byID[ref.ID] = g
+				together[ref.ID] = make(map[string]int)
+			}
+			if g.URL == "" {
+				g.URL = ref.URL
+			}
+			g.Appearances++
+			g.Last = appearance
+			g.EpisodeIDs = append(g.EpisodeIDs, e.ID)
+			for _, other := range refs {
+				if other.ID != ref.ID {
+					together[ref.ID][other.ID]++
+				}
+			}
+		}
+	}
+
+	guests := make([]Guest, 0, len(byID))
+	for id, g := range byID {
+		for otherID, n := range together[id] {
+			g.CoGuests = append(g.CoGuests, CoGuest{ID: otherID, Name: byID[otherID].Name, Episodes: n})
+		}
+		sort.Slice(g.CoGuests, func(i, j int) bool {
+			a, b := g.CoGuests[i], g.CoGuests[j]
+			if a.Episodes != b.Episodes {
+				return a.Episodes > b.Episodes
+			}
+			return a.Name < b.Name
+		})
+		if len(g.CoGuests) > MaxCoGuests {
+			g.CoGuests = g.CoGuests[:MaxCoGuests]
+		}
+		guests = append(guests, *g)
+	}
+	SortGuests(guests)
+	return guests
+}
+
+// SortGuests orders guests by appearances, most first, then by name.
+func SortGuests(guests []Guest) {
+	sort.Slice(guests, func(i, j int) bool {
+		if guests[i].Appearances != guests[j].Appearances {
+			return guests[i].Appearances > guests[j].Appearances
+		}
+		return guests[i].Name < guests[j].Name
+	})
+}
+
+// AppearsBefore orders episodes by date, undated ones last, then by
+// episode number and ID.
+func AppearsBefore(a, b Episode) bool {
+	if a.Timestamp.IsZero() != b.Timestamp.IsZero() {
+		return !a.Timestamp.IsZero()
+	}
+	if !a.Timestamp.Equal(b.Timestamp) {
+		return a.Timestamp.Before(b.Timestamp)
+	}
+	if a.Numbering.SortKey != b.Numbering.SortKey {
+		return a.Numbering.SortKey < b.Numbering.SortKey
+	}
+	return a.ID < b.ID
+}
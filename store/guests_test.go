@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"webscraper/scraper"
+)
+
+func guestEpisode(id string, day int, guests ...string) Episode {
+	e := Episode{Episode: scraper.Episode{ID: id, EpisodeNo: id, Title: "Episode " + id}}
+	if day > 0 {
+		e.Timestamp = time.Date(2016, 1, day, 0, 0, 0, 0, time.UTC)
+	}
+	for _, name := range guests {
+		e.GuestRefs = append(e.GuestRefs, scraper.Guest{ID: scraper.GuestID(name), Name: name})
+		e.Guests = append(e.Guests, name)
+	}
+	return e
+}
+
+func TestBuildGuests(t *testing.T) {
+	jake := "Jake Longstreth"
+	episodes := []Episode{
+		guestEpisode("3", 20, jake, "Jonah Hill"),
+		guestEpisode("1", 1, jake, "Jonah Hill", "Seth Rogen"),
+		guestEpisode("9", 0, jake), // undated, sorts last
+		guestEpisode("2", 10, "Seth Rogen"),
+	}
+	// An episode stored before guests were normalized.
+	legacy := guestEpisode("4", 25)
+	legacy.Guests = []string{jake}
+	episodes = append(episodes, legacy)
+	episodes[1].GuestRefs[0].URL = "https://example.com/wiki/Jake_Longstreth"
+
+	guests := BuildGuests(episodes)
+	var names []string
+	for _, g := range guests {
+		names = append(names, g.Name)
+	}
+	if want := []string{jake, "Jonah Hill", "Seth Rogen"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("order: got %v, want %v", names, want)
+	}
+
+	g := guests[0]
+	if g.ID != "jake-longstreth" || g.URL != "https://example.com/wiki/Jake_Longstreth" || g.Appearances != 4 {
+		t.Errorf("jake: %+v", g)
+	}
+	if want := []string{"1", "3", "4", "9"}; !reflect.DeepEqual(g.EpisodeIDs, want) {
+		t.Errorf("episode IDs: got %v, want %v", g.EpisodeIDs, want)
+	}
+	if g.First.EpisodeID != "1" || g.Last.EpisodeID != "9" {
+		t.Errorf("first %s, last %s", g.First.EpisodeID, g.Last.EpisodeID)
+	}
+	wantCo := []CoGuest{{ID: "jonah-hill", Name: "Jonah Hill", Episodes: 2}, {ID: "seth-rogen", Name: "Seth Rogen", Episodes: 1}}
+	if !reflect.DeepEqual(g.CoGuests, wantCo) {
+		t.Errorf("co-guests: got %+v, want %+v", g.CoGuests, wantCo)
+	}
+}
+
+func TestReplaceGuests(t *testing.T) {
+	ctx := context.Background()
+	guests := BuildGuests([]Episode{
+		guestEpisode("1", 1, "Jake Longstreth", "Jonah Hill"),
+		guestEpisode("2", 2, "Jake Longstreth"),
+	})
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := s.ReplaceGuests(ctx, []Guest{{ID: "old", Name: "Old"}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.ReplaceGuests(ctx, guests); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Guests(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, guests) {
+				t.Errorf("got %+v\nwant %+v", got, guests)
+			}
+		})
+	}
+}
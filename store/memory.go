@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory is an EpisodeStore that keeps everything in a map. It is meant for
+// tests and dry runs; nothing survives the process.
+type Memory struct {
+	mu       sync.Mutex
+	episodes map[string]Episode
+	guests   []Guest
+}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{episodes: make(map[string]Episode)}
+}
+
+func (m *Memory) Get(ctx context.Context, id string) (Episode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.episodes[id]
+	if !ok {
+		return Episode{}, ErrNotFound
+	}
+	return e, nil
+}
+
+func (m *Memory) Upsert(ctx context.Context, episodes []Episode) (UpsertResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result UpsertResult
+	for _, e := range episodes {
+		old, ok := m.episodes[e.ID]
+		if ok {
+			result.Updated++
+		} else {
+			result.Inserted++
+		}
+		if e.Embedding == nil {
+			e.Embedding, e.EmbeddingHash, e.EmbeddingModel = old.Embedding, old.EmbeddingHash, old.EmbeddingModel
+		}
+		m.episodes[e.ID] = e
+	}
+	return result, nil
+}
+
+func (m *Memory) List(ctx context.Context, opts ListOptions) (Page, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.episodes))
+	for id, e := range m.episodes {
+		if id > opts.After && opts.Filter.Match(e) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var page Page
+	limit := listLimit(opts)
+	if len(ids) > limit {
+		ids = ids[:limit]
+		page.Next = ids[limit-1]
+	}
+	for _, id := range ids {
+		page.Episodes = append(page.Episodes, m.episodes[id])
+	}
+	return page, nil
+}
+
+func (m *Memory) SetEmbedding(ctx context.Context, id string, emb Embedding) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.episodes[id]
+	if !ok {
+		return ErrNotFound
+	}
+	e.Embedding, e.EmbeddingHash, e.EmbeddingModel = emb.Vector, emb.Hash, emb.Model
+	m.episodes[id] = e
+	return nil
+}
+
+func (m *Memory) VectorSearch(ctx context.Context, vector []float32, opts SearchOptions) ([]Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	candidates := make([]Episode, 0, len(m.episodes))
+	for _, e := range m.episodes {
+		candidates = append(candidates, e)
+	}
+	// Map order is random; sort so equal scores come back in a stable order.
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+	return rank(candidates, vector, opts), nil
+}
+
+func (m *Memory) Hashes(ctx context.Context) (map[string]Hashes, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hashes := make(map[string]Hashes, len(m.episodes))
+	for id, e := range m.episodes {
+		hashes[id] = Hashes{ContentHash: e.ContentHash, EmbeddingHash: e.EmbeddingHash, EmbeddingModel: e.EmbeddingModel}
+	}
+	return hashes, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, ids []string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := 0
+	for _, id := range ids {
+		if _, ok := m.episodes[id]; ok {
+			delete(m.episodes, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *Memory) ReplaceGuests(ctx context.Context, guests []Guest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.guests = append([]Guest(nil), guests...)
+	return nil
+}
+
+func (m *Memory) Guests(ctx context.Context) ([]Guest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	guests := append([]Guest(nil), m.guests...)
+	SortGuests(guests)
+	return guests, nil
+}
+
+func (m *Memory) Close(ctx context.Context) error { return nil }
@@ -0,0 +1,414 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"webscraper/embedding"
+)
+
+// Mongo is an EpisodeStore backed by a MongoDB collection, one document
+// per episode keyed by _id.
+type Mongo struct {
+	collection *mongo.Collection
+
+	// VectorIndex names the Atlas Vector Search index on embedding. When
+	// set, VectorSearch runs a $vectorSearch stage and only falls back to
+	// ranking in process when the stage fails or finds nothing, as it does
+	// outside Atlas or before the index is built.
+	VectorIndex string
+
+	// GuestCollection names the collection, in the same database, holding
+	// the derived guest records. Defaults to "guests".
+	GuestCollection string
+
+	// textIndexMu guards textIndexReady, which is set once the text index
+	// exists, so that a failed creation is tried again on the next search.
+	textIndexMu    sync.Mutex
+	textIndexReady bool
+}
+
+// textIndexTimeout bounds creating the text index, which can outlast a
+// search request's deadline on a large collection.
+const textIndexTimeout = time.Minute
+
+// NewMongo wraps an episode collection. Close disconnects its client.
+func NewMongo(collection *mongo.Collection) *Mongo {
+	return &Mongo{collection: collection}
+}
+
+// Collection returns the underlying collection, for one-off migrations
+// that need more than EpisodeStore offers.
+func (m *Mongo) Collection() *mongo.Collection { return m.collection }
+
+func (m *Mongo) Get(ctx context.Context, id string) (Episode, error) {
+	var e Episode
+	err := m.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&e)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return e, ErrNotFound
+	}
+	return e, err
+}
+
+func (m *Mongo) Upsert(ctx context.Context, episodes []Episode) (UpsertResult, error) {
+	if len(episodes) == 0 {
+		return UpsertResult{}, nil
+	}
+	models := make([]mongo.WriteModel, len(episodes))
+	for i, e := range episodes {
+		set := episodeFields(e)
+		if e.Embedding != nil {
+			set["embedding"] = e.Embedding
+			set["embedding_hash"] = e.EmbeddingHash
+			set["embedding_model"] = e.EmbeddingModel
+		}
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": e.ID}).
+			SetUpdate(bson.M{"$set": set}).
+			SetUpsert(true)
+	}
+
+	var result UpsertResult
+	res, err := m.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if res != nil {
+		result.Inserted = int(res.UpsertedCount)
+		result.Updated = int(res.ModifiedCount)
+	}
+	return result, err
+}
+
+func (m *Mongo) List(ctx context.Context, opts ListOptions) (Page, error) {
+	filter := mongoFilter(opts.Filter)
+	if opts.After != "" {
+		filter["_id"] = bson.M{"$gt": opts.After}
+	}
+	limit := listLimit(opts)
+	find := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(limit + 1))
+	cursor, err := m.collection.Find(ctx, filter, find)
+	if err != nil {
+		return Page{}, err
+	}
+	var page Page
+	if err := cursor.All(ctx, &page.Episodes); err != nil {
+		return Page{}, err
+	}
+	if len(page.Episodes) > limit {
+		page.Episodes = page.Episodes[:limit]
+		page.Next = page.Episodes[limit-1].ID
+	}
+	return page, nil
+}
+
+func (m *Mongo) SetEmbedding(ctx context.Context, id string, e Embedding) error {
+	update := bson.M{"$set": bson.M{
+		"embedding":       e.Vector,
+		"embedding_hash":  e.Hash,
+		"embedding_model": e.Model,
+	}}
+	res, err := m.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *Mongo) VectorSearch(ctx context.Context, vector []float32, opts SearchOptions) ([]Match, error) {
+	if m.VectorIndex != "" {
+		matches, err := m.atlasSearch(ctx, vector, opts)
+		if err == nil && len(matches) > 0 {
+			return matches, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+	}
+
+	filter := mongoFilter(opts.Filter)
+	filter["embedding"] = bson.M{"$exists": true}
+	if models := mongoModels(opts.Model); models != nil {
+		filter["embedding_model"] = bson.M{"$in": models}
+	}
+	cursor, err := m.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []Episode
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+	return rank(candidates, vector, opts), nil
+}
+
+// atlasSearch runs an Atlas $vectorSearch. The model and filter are applied
+// after the search, so it asks for more candidates than it returns.
+func (m *Mongo) atlasSearch(ctx context.Context, vector []float32, opts SearchOptions) ([]Match, error) {
+	limit := searchLimit(opts)
+	match := mongoFilter(opts.Filter)
+	if models := mongoModels(opts.Model); models != nil {
+		match["embedding_model"] = bson.M{"$in": models}
+	}
+	fetch := limit
+	if len(match) > 0 {
+		fetch = limit * 10
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$vectorSearch", Value: bson.M{
+			"index":         m.VectorIndex,
+			"path":          "embedding",
+			"queryVector":   vector,
+			"numCandidates": min(fetch*10, 10000),
+			"limit":         min(fetch, 10000),
+		}}},
+		{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "vectorSearchScore"}}}},
+		{{Key: "$match", Value: match}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := m.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		Episode `bson:",inline"`
+		Score   float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	matches := make([]Match, len(docs))
+	for i, d := range docs {
+		// Atlas reports cosine similarity rescaled to 0..1.
+		matches[i] = Match{Episode: d.Episode, Score: 2*d.Score - 1}
+	}
+	return matches, nil
+}
+
+// ensureTextIndex creates the text index unless an earlier call did. The
+// index gets its own deadline rather than the caller's, so that a short
+// search timeout doesn't cancel it halfway.
+func (m *Mongo) ensureTextIndex(ctx context.Context) error {
+	m.textIndexMu.Lock()
+	defer m.textIndexMu.Unlock()
+	if m.textIndexReady {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), textIndexTimeout)
+	defer cancel()
+	_, err := m.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "title", Value: "text"},
+			{Key: "notes", Value: "text"},
+			{Key: "guests", Value: "text"},
+			{Key: "episode_no", Value: "text"},
+		},
+		Options: options.Index().SetName("episode_text"),
+	})
+	if err != nil {
+		return err
+	}
+	m.textIndexReady = true
+	return nil
+}
+
+// TextSearch runs a $text query, creating the text index over title,
+// notes, guests and episode_no on first use. opts.Model is ignored.
+func (m *Mongo) TextSearch(ctx context.Context, query string, opts SearchOptions) ([]Match, error) {
+	if err := m.ensureTextIndex(ctx); err != nil {
+		return nil, fmt.Errorf("create text index: %w", err)
+	}
+
+	filter := mongoFilter(opts.Filter)
+	filter["$text"] = bson.M{"$search": query}
+	score := bson.M{"score": bson.M{"$meta": "textScore"}}
+	find := options.Find().
+		SetProjection(score).
+		SetSort(score).
+		SetLimit(int64(searchLimit(opts)))
+	cursor, err := m.collection.Find(ctx, filter, find)
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		Episode `bson:",inline"`
+		Score   float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	matches := make([]Match, len(docs))
+	for i, d := range docs {
+		matches[i] = Match{Episode: d.Episode, Score: d.Score}
+	}
+	return matches, nil
+}
+
+// mongoModels lists the embedding_model values that match model. Documents
+// without the field were embedded with the default model.
+func mongoModels(model string) []interface{} {
+	if model == "" {
+		return nil
+	}
+	models := []interface{}{model}
+	if model == embedding.DefaultOpenAIModel {
+		models = append(models, nil, "")
+	}
+	return models
+}
+
+// mongoFilter translates f into a query document.
+func mongoFilter(f Filter) bson.M {
+	query := bson.M{}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		timestamp := bson.M{"$gt": time.Time{}}
+		if !f.From.IsZero() {
+			timestamp["$gte"] = f.From
+		}
+		if !f.To.IsZero() {
+			timestamp["$lte"] = f.To
+		}
+		query["timestamp"] = timestamp
+	}
+	if f.Guest != "" {
+		query["guests"] = containsFold(f.Guest)
+	}
+	if f.Text != "" {
+		query["$or"] = bson.A{
+			bson.M{"title": containsFold(f.Text)},
+			bson.M{"notes": containsFold(f.Text)},
+			bson.M{"guests": containsFold(f.Text)},
+		}
+	}
+	if f.NumberFrom != 0 || f.NumberTo != 0 {
+		number := bson.M{"$gt": 0}
+		if f.NumberFrom != 0 {
+			number["$gte"] = f.NumberFrom
+		}
+		if f.NumberTo != 0 {
+			number["$lte"] = f.NumberTo
+		}
+		query["numbering.number"] = number
+	}
+	if f.Kind != "" {
+		query["numbering.kind"] = f.Kind
+	}
+	return query
+}
+
+func containsFold(s string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(s), "$options": "i"}
+}
+
+// Hashes reads only the hash fields. Documents written before hashes
+// existed have empty hashes.
+func (m *Mongo) Hashes(ctx context.Context) (map[string]Hashes, error) {
+	projection := bson.M{"content_hash": 1, "embedding_hash": 1, "embedding_model": 1}
+	cursor, err := m.collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	hashes := make(map[string]Hashes)
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID     string `bson:"_id"`
+			Hashes `bson:",inline"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		hashes[doc.ID] = doc.Hashes
+	}
+	return hashes, cursor.Err()
+}
+
+func (m *Mongo) Delete(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res, err := m.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.DeletedCount), nil
+}
+
+func (m *Mongo) guests() *mongo.Collection {
+	name := m.GuestCollection
+	if name == "" {
+		name = "guests"
+	}
+	return m.collection.Database().Collection(name)
+}
+
+// ReplaceGuests empties the guest collection and inserts guests. Readers
+// may briefly see an empty or partial collection.
+func (m *Mongo) ReplaceGuests(ctx context.Context, guests []Guest) error {
+	coll := m.guests()
+	if _, err := coll.DeleteMany(ctx, bson.M{}); err != nil {
+		return err
+	}
+	if len(guests) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(guests))
+	for i, g := range guests {
+		docs[i] = g
+	}
+	_, err := coll.InsertMany(ctx, docs)
+	return err
+}
+
+func (m *Mongo) Guests(ctx context.Context) ([]Guest, error) {
+	find := options.Find().SetSort(bson.D{{Key: "appearances", Value: -1}, {Key: "name", Value: 1}})
+	cursor, err := m.guests().Find(ctx, bson.M{}, find)
+	if err != nil {
+		return nil, err
+	}
+	var guests []Guest
+	if err := cursor.All(ctx, &guests); err != nil {
+		return nil, err
+	}
+	return guests, nil
+}
+
+func (m *Mongo) Close(ctx context.Context) error {
+	return m.collection.Database().Client().Disconnect(ctx)
+}
+
+// episodeFields lists every scraped field explicitly so that values which
+// became empty on the wiki are cleared instead of skipped by omitempty.
+func episodeFields(e Episode) bson.M {
+	return bson.M{
+		"url":                   e.Url,
+		"title":                 e.Title,
+		"episode_no":            e.EpisodeNo,
+		"numbering":             e.Numbering,
+		"season":                e.Season,
+		"date":                  e.Date,
+		"formatted_date":        e.FormattedDate,
+		"timestamp":             e.Timestamp,
+		"date_precision":        e.DatePrecision,
+		"date_status":           e.DateStatus,
+		"guests":                e.Guests,
+		"guest_refs":            e.GuestRefs,
+		"raw_guests":            e.RawGuests,
+		"top_5_comparison_year": e.Top5ComparisonYear,
+		"notes":                 e.Notes,
+		"note_links":            e.NoteLinks,
+		"note_footnotes":        e.NoteFootnotes,
+		"content":               e.Content,
+		"content_hash":          e.ContentHash,
+	}
+}
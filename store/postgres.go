@@ -0,0 +1,420 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+)
+
+// The embedding column has no fixed dimension so that models of different
+// sizes can share the table; VectorSearch only compares vectors of the
+// query's dimension.
+const postgresSchema = `
+CREATE EXTENSION IF NOT EXISTS vector;
+CREATE TABLE IF NOT EXISTS episodes (
+	id              text PRIMARY KEY,
+	doc             jsonb NOT NULL,
+	content_hash    text NOT NULL DEFAULT '',
+	embedding       vector,
+	embedding_hash  text NOT NULL DEFAULT '',
+	embedding_model text NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS guests (
+	id          text PRIMARY KEY,
+	name        text NOT NULL,
+	appearances integer NOT NULL,
+	doc         jsonb NOT NULL
+)`
+
+// Postgres is an EpisodeStore in a PostgreSQL table with the pgvector
+// extension. Like SQLite, each row holds the scraped episode as JSON next
+// to its hashes; the embedding is a pgvector column so nearest-neighbour
+// queries run in the database.
+type Postgres struct {
+	pool *pgxpool.Pool
+}
+
+// OpenPostgres connects to the database at url and creates the pgvector
+// extension and the episodes table if they do not exist.
+func OpenPostgres(ctx context.Context, url string) (*Postgres, error) {
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, postgresSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &Postgres{pool: pool}, nil
+}
+
+const postgresColumns = `id, doc, content_hash, embedding::text, embedding_hash, embedding_model`
+
+func (p *Postgres) Get(ctx context.Context, id string) (Episode, error) {
+	row := p.pool.QueryRow(ctx, `SELECT `+postgresColumns+` FROM episodes WHERE id = $1`, id)
+	e, err := scanPostgresEpisode(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return e, ErrNotFound
+	}
+	return e, err
+}
+
+func (p *Postgres) Upsert(ctx context.Context, episodes []Episode) (UpsertResult, error) {
+	var result UpsertResult
+	if len(episodes) == 0 {
+		return result, nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, e := range episodes {
+		doc, err := json.Marshal(e.Episode)
+		if err != nil {
+			return result, err
+		}
+		// xmax is zero for a freshly inserted row and set when the
+		// conflict clause updated an existing one.
+		if e.Embedding != nil {
+			batch.Queue(`
+INSERT INTO episodes (id, doc, content_hash, embedding, embedding_hash, embedding_model)
+VALUES ($1, $2, $3, $4::vector, $5, $6)
+ON CONFLICT (id) DO UPDATE SET
+	doc = excluded.doc,
+	content_hash = excluded.content_hash,
+	embedding = excluded.embedding,
+	embedding_hash = excluded.embedding_hash,
+	embedding_model = excluded.embedding_model
+RETURNING xmax = 0`,
+				e.ID, doc, e.ContentHash, formatVector(e.Embedding), e.EmbeddingHash, e.EmbeddingModel)
+		} else {
+			batch.Queue(`
+INSERT INTO episodes (id, doc, content_hash) VALUES ($1, $2, $3)
+ON CONFLICT (id) DO UPDATE SET
+	doc = excluded.doc,
+	content_hash = excluded.content_hash
+RETURNING xmax = 0`,
+				e.ID, doc, e.ContentHash)
+		}
+	}
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback(ctx)
+
+	results := tx.SendBatch(ctx, batch)
+	for _, e := range episodes {
+		var inserted bool
+		if err := results.QueryRow().Scan(&inserted); err != nil {
+			results.Close()
+			return UpsertResult{}, fmt.Errorf("upsert %s: %w", e.ID, err)
+		}
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	if err := results.Close(); err != nil {
+		return UpsertResult{}, err
+	}
+	return result, tx.Commit(ctx)
+}
+
+func (p *Postgres) List(ctx context.Context, opts ListOptions) (Page, error) {
+	limit := listLimit(opts)
+	args := []any{opts.After}
+	where := postgresFilter(opts.Filter, &args)
+	args = append(args, limit+1)
+	rows, err := p.pool.Query(ctx,
+		`SELECT `+postgresColumns+` FROM episodes WHERE id > $1`+where+` ORDER BY id LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return Page{}, err
+	}
+	episodes, err := scanPostgresEpisodes(rows)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page := Page{Episodes: episodes}
+	if len(episodes) > limit {
+		page.Episodes = episodes[:limit]
+		page.Next = episodes[limit-1].ID
+	}
+	return page, nil
+}
+
+func (p *Postgres) SetEmbedding(ctx context.Context, id string, e Embedding) error {
+	tag, err := p.pool.Exec(ctx,
+		`UPDATE episodes SET embedding = $1::vector, embedding_hash = $2, embedding_model = $3 WHERE id = $4`,
+		formatVector(e.Vector), e.Hash, e.Model, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// VectorSearch orders by pgvector's cosine distance operator.
+func (p *Postgres) VectorSearch(ctx context.Context, vector []float32, opts SearchOptions) ([]Match, error) {
+	if len(vector) == 0 {
+		return nil, nil
+	}
+	args := []any{formatVector(vector), len(vector), opts.Model, embedding.DefaultOpenAIModel}
+	where := postgresFilter(opts.Filter, &args)
+	args = append(args, searchLimit(opts))
+	rows, err := p.pool.Query(ctx, `
+SELECT `+postgresColumns+`, 1 - (embedding <=> $1::vector)
+FROM episodes
+WHERE embedding IS NOT NULL
+	AND vector_dims(embedding) = $2
+	AND ($3 = '' OR embedding_model = $3 OR (embedding_model = '' AND $3 = $4))`+where+`
+ORDER BY embedding <=> $1::vector, id
+LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []Match
+	for rows.Next() {
+		var m Match
+		var doc, vec string
+		var embedded *string
+		err := rows.Scan(&m.Episode.ID, &doc, &m.Episode.ContentHash, &embedded,
+			&m.Episode.EmbeddingHash, &m.Episode.EmbeddingModel, &m.Score)
+		if err != nil {
+			return nil, err
+		}
+		if embedded != nil {
+			vec = *embedded
+		}
+		if err := decodePostgresEpisode(&m.Episode, doc, vec); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// postgresFilter returns the SQL conditions for f, each starting with AND,
+// appending their parameters to args.
+func postgresFilter(f Filter, args *[]any) string {
+	var b strings.Builder
+	param := func(v any) string {
+		*args = append(*args, v)
+		return "$" + strconv.Itoa(len(*args))
+	}
+	const timestamp = `(doc->>'timestamp')::timestamptz`
+	if !f.From.IsZero() || !f.To.IsZero() {
+		b.WriteString("\n\tAND " + timestamp + " > '0001-01-01T00:00:00Z'")
+	}
+	if !f.From.IsZero() {
+		b.WriteString("\n\tAND " + timestamp + " >= " + param(f.From))
+	}
+	if !f.To.IsZero() {
+		b.WriteString("\n\tAND " + timestamp + " <= " + param(f.To))
+	}
+	guest := func(p string) string {
+		return "EXISTS (SELECT 1 FROM jsonb_array_elements_text(doc->'guests') g WHERE strpos(lower(g), lower(" + p + ")) > 0)"
+	}
+	if f.Guest != "" {
+		b.WriteString("\n\tAND " + guest(param(f.Guest)))
+	}
+	if f.Text != "" {
+		p := param(f.Text)
+		b.WriteString("\n\tAND (strpos(lower(doc->>'title'), lower(" + p + ")) > 0" +
+			" OR strpos(lower(doc->>'notes'), lower(" + p + ")) > 0" +
+			" OR " + guest(p) + ")")
+	}
+	const number = `coalesce((doc->'numbering'->>'number')::int, 0)`
+	if f.NumberFrom != 0 || f.NumberTo != 0 {
+		b.WriteString("\n\tAND " + number + " > 0")
+	}
+	if f.NumberFrom != 0 {
+		b.WriteString("\n\tAND " + number + " >= " + param(f.NumberFrom))
+	}
+	if f.NumberTo != 0 {
+		b.WriteString("\n\tAND " + number + " <= " + param(f.NumberTo))
+	}
+	if f.Kind != "" {
+		b.WriteString("\n\tAND doc->'numbering'->>'kind' = " + param(f.Kind))
+	}
+	return b.String()
+}
+
+func (p *Postgres) Hashes(ctx context.Context) (map[string]Hashes, error) {
+	rows, err := p.pool.Query(ctx, `SELECT id, content_hash, embedding_hash, embedding_model FROM episodes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]Hashes)
+	for rows.Next() {
+		var id string
+		var h Hashes
+		if err := rows.Scan(&id, &h.ContentHash, &h.EmbeddingHash, &h.EmbeddingModel); err != nil {
+			return nil, err
+		}
+		hashes[id] = h
+	}
+	return hashes, rows.Err()
+}
+
+func (p *Postgres) Delete(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	tag, err := p.pool.Exec(ctx, `DELETE FROM episodes WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// ReplaceGuests swaps the guest records in one transaction.
+func (p *Postgres) ReplaceGuests(ctx context.Context, guests []Guest) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM guests`); err != nil {
+		return err
+	}
+	batch := &pgx.Batch{}
+	for _, g := range guests {
+		doc, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		batch.Queue(`INSERT INTO guests (id, name, appearances, doc) VALUES ($1, $2, $3, $4)`,
+			g.ID, g.Name, g.Appearances, doc)
+	}
+	if batch.Len() > 0 {
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (p *Postgres) Guests(ctx context.Context) ([]Guest, error) {
+	rows, err := p.pool.Query(ctx, `SELECT doc FROM guests ORDER BY appearances DESC, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var guests []Guest
+	for rows.Next() {
+		var doc []byte
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var g Guest
+		if err := json.Unmarshal(doc, &g); err != nil {
+			return nil, err
+		}
+		guests = append(guests, g)
+	}
+	return guests, rows.Err()
+}
+
+func (p *Postgres) Close(ctx context.Context) error {
+	p.pool.Close()
+	return nil
+}
+
+func scanPostgresEpisode(row pgx.Row) (Episode, error) {
+	var e Episode
+	var doc string
+	var vector *string
+	if err := row.Scan(&e.ID, &doc, &e.ContentHash, &vector, &e.EmbeddingHash, &e.EmbeddingModel); err != nil {
+		return Episode{}, err
+	}
+	var vec string
+	if vector != nil {
+		vec = *vector
+	}
+	return e, decodePostgresEpisode(&e, doc, vec)
+}
+
+func scanPostgresEpisodes(rows pgx.Rows) ([]Episode, error) {
+	defer rows.Close()
+	var episodes []Episode
+	for rows.Next() {
+		e, err := scanPostgresEpisode(rows)
+		if err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, e)
+	}
+	return episodes, rows.Err()
+}
+
+func decodePostgresEpisode(e *Episode, doc, vector string) error {
+	var scraped scraper.Episode
+	if err := json.Unmarshal([]byte(doc), &scraped); err != nil {
+		return fmt.Errorf("decode episode %s: %w", e.ID, err)
+	}
+	e.Episode = scraped
+	if vector == "" {
+		return nil
+	}
+	v, err := parseVector(vector)
+	if err != nil {
+		return fmt.Errorf("decode embedding of %s: %w", e.ID, err)
+	}
+	e.Embedding = v
+	return nil
+}
+
+// formatVector writes v in pgvector's text format, e.g. "[1,0.5,-2]".
+func formatVector(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parseVector reads pgvector's text format.
+func parseVector(s string) ([]float32, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("malformed vector %.20q", s)
+	}
+	s = s[1 : len(s)-1]
+	if s == "" {
+		return []float32{}, nil
+	}
+	parts := strings.Split(s, ",")
+	v := make([]float32, len(parts))
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, err
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
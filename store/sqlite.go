@@ -0,0 +1,352 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go driver, registered as "sqlite"
+
+	"webscraper/scraper"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS episodes (
+	id              TEXT PRIMARY KEY,
+	doc             TEXT NOT NULL,
+	content_hash    TEXT NOT NULL DEFAULT '',
+	embedding       BLOB,
+	embedding_hash  TEXT NOT NULL DEFAULT '',
+	embedding_model TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS guests (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	appearances INTEGER NOT NULL,
+	doc         TEXT NOT NULL
+)`
+
+// SQLite is an EpisodeStore in a single SQLite file, for running the
+// pipeline locally. Each row holds the scraped episode as JSON next to its
+// hashes and its embedding as little-endian float32s. Vector search scans
+// every row, which is fine at the size of the guide.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens or creates the database at path. Use ":memory:" for a
+// throwaway database.
+func OpenSQLite(ctx context.Context, path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// A :memory: database lives as long as its connection.
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+const sqliteColumns = `id, doc, content_hash, embedding, embedding_hash, embedding_model`
+
+func (s *SQLite) Get(ctx context.Context, id string) (Episode, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sqliteColumns+` FROM episodes WHERE id = ?`, id)
+	e, err := scanEpisode(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e, ErrNotFound
+	}
+	return e, err
+}
+
+func (s *SQLite) Upsert(ctx context.Context, episodes []Episode) (UpsertResult, error) {
+	var result UpsertResult
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	for _, e := range episodes {
+		doc, err := json.Marshal(e.Episode)
+		if err != nil {
+			return UpsertResult{}, err
+		}
+
+		var exists bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM episodes WHERE id = ?)`, e.ID).Scan(&exists)
+		if err != nil {
+			return UpsertResult{}, err
+		}
+
+		switch {
+		case e.Embedding != nil:
+			_, err = tx.ExecContext(ctx, `
+INSERT INTO episodes (`+sqliteColumns+`) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+	doc = excluded.doc,
+	content_hash = excluded.content_hash,
+	embedding = excluded.embedding,
+	embedding_hash = excluded.embedding_hash,
+	embedding_model = excluded.embedding_model`,
+				e.ID, doc, e.ContentHash, encodeVector(e.Embedding), e.EmbeddingHash, e.EmbeddingModel)
+		default:
+			_, err = tx.ExecContext(ctx, `
+INSERT INTO episodes (id, doc, content_hash) VALUES (?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+	doc = excluded.doc,
+	content_hash = excluded.content_hash`,
+				e.ID, doc, e.ContentHash)
+		}
+		if err != nil {
+			return UpsertResult{}, fmt.Errorf("upsert %s: %w", e.ID, err)
+		}
+		if exists {
+			result.Updated++
+		} else {
+			result.Inserted++
+		}
+	}
+	return result, tx.Commit()
+}
+
+func (s *SQLite) List(ctx context.Context, opts ListOptions) (Page, error) {
+	limit := listLimit(opts)
+	args := []any{opts.After}
+	where := sqliteFilter(opts.Filter, &args)
+	args = append(args, limit+1)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+sqliteColumns+` FROM episodes WHERE id > ?`+where+` ORDER BY id LIMIT ?`, args...)
+	if err != nil {
+		return Page{}, err
+	}
+	episodes, err := scanEpisodes(rows)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page := Page{Episodes: episodes}
+	if len(episodes) > limit {
+		page.Episodes = episodes[:limit]
+		page.Next = episodes[limit-1].ID
+	}
+	return page, nil
+}
+
+func (s *SQLite) SetEmbedding(ctx context.Context, id string, e Embedding) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE episodes SET embedding = ?, embedding_hash = ?, embedding_model = ? WHERE id = ?`,
+		encodeVector(e.Vector), e.Hash, e.Model, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLite) VectorSearch(ctx context.Context, vector []float32, opts SearchOptions) ([]Match, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+sqliteColumns+` FROM episodes WHERE embedding IS NOT NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := scanEpisodes(rows)
+	if err != nil {
+		return nil, err
+	}
+	return rank(candidates, vector, opts), nil
+}
+
+func (s *SQLite) Hashes(ctx context.Context) (map[string]Hashes, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, content_hash, embedding_hash, embedding_model FROM episodes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]Hashes)
+	for rows.Next() {
+		var id string
+		var h Hashes
+		if err := rows.Scan(&id, &h.ContentHash, &h.EmbeddingHash, &h.EmbeddingModel); err != nil {
+			return nil, err
+		}
+		hashes[id] = h
+	}
+	return hashes, rows.Err()
+}
+
+func (s *SQLite) Delete(ctx context.Context, ids []string) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	deleted := 0
+	for _, id := range ids {
+		res, err := tx.ExecContext(ctx, `DELETE FROM episodes WHERE id = ?`, id)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := res.RowsAffected()
+		deleted += int(n)
+	}
+	return deleted, tx.Commit()
+}
+
+func (s *SQLite) ReplaceGuests(ctx context.Context, guests []Guest) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM guests`); err != nil {
+		return err
+	}
+	for _, g := range guests {
+		doc, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO guests (id, name, appearances, doc) VALUES (?, ?, ?, ?)`,
+			g.ID, g.Name, g.Appearances, doc)
+		if err != nil {
+			return fmt.Errorf("insert guest %s: %w", g.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) Guests(ctx context.Context) ([]Guest, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT doc FROM guests ORDER BY appearances DESC, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var guests []Guest
+	for rows.Next() {
+		var doc string
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var g Guest
+		if err := json.Unmarshal([]byte(doc), &g); err != nil {
+			return nil, err
+		}
+		guests = append(guests, g)
+	}
+	return guests, rows.Err()
+}
+
+func (s *SQLite) Close(ctx context.Context) error { return s.db.Close() }
+
+// sqliteFilter returns the SQL conditions for f, each starting with AND,
+// appending their parameters to args. It mirrors Filter.Match.
+func sqliteFilter(f Filter, args *[]any) string {
+	var b strings.Builder
+	const timestamp = `julianday(json_extract(doc, '$.timestamp'))`
+	if !f.From.IsZero() || !f.To.IsZero() {
+		b.WriteString(" AND " + timestamp + " > julianday('0001-01-01T00:00:00Z')")
+	}
+	if !f.From.IsZero() {
+		b.WriteString(" AND " + timestamp + " >= julianday(?)")
+		*args = append(*args, f.From.UTC().Format(time.RFC3339Nano))
+	}
+	if !f.To.IsZero() {
+		b.WriteString(" AND " + timestamp + " <= julianday(?)")
+		*args = append(*args, f.To.UTC().Format(time.RFC3339Nano))
+	}
+	const guest = `EXISTS (SELECT 1 FROM json_each(doc, '$.guests') WHERE instr(lower(value), lower(?)) > 0)`
+	if f.Guest != "" {
+		b.WriteString(" AND " + guest)
+		*args = append(*args, f.Guest)
+	}
+	if f.Text != "" {
+		b.WriteString(" AND (instr(lower(coalesce(json_extract(doc, '$.title'), '')), lower(?)) > 0" +
+			" OR instr(lower(coalesce(json_extract(doc, '$.notes'), '')), lower(?)) > 0" +
+			" OR " + guest + ")")
+		*args = append(*args, f.Text, f.Text, f.Text)
+	}
+	const number = `coalesce(json_extract(doc, '$.numbering.number'), 0)`
+	if f.NumberFrom != 0 || f.NumberTo != 0 {
+		b.WriteString(" AND " + number + " > 0")
+	}
+	if f.NumberFrom != 0 {
+		b.WriteString(" AND " + number + " >= ?")
+		*args = append(*args, f.NumberFrom)
+	}
+	if f.NumberTo != 0 {
+		b.WriteString(" AND " + number + " <= ?")
+		*args = append(*args, f.NumberTo)
+	}
+	if f.Kind != "" {
+		b.WriteString(" AND json_extract(doc, '$.numbering.kind') = ?")
+		*args = append(*args, f.Kind)
+	}
+	return b.String()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEpisode(row rowScanner) (Episode, error) {
+	var e Episode
+	var doc string
+	var vector []byte
+	if err := row.Scan(&e.ID, &doc, &e.ContentHash, &vector, &e.EmbeddingHash, &e.EmbeddingModel); err != nil {
+		return Episode{}, err
+	}
+	var scraped scraper.Episode
+	if err := json.Unmarshal([]byte(doc), &scraped); err != nil {
+		return Episode{}, fmt.Errorf("decode episode %s: %w", e.ID, err)
+	}
+	e.Episode = scraped
+	e.Embedding = decodeVector(vector)
+	return e, nil
+}
+
+func scanEpisodes(rows *sql.Rows) ([]Episode, error) {
+	defer rows.Close()
+	var episodes []Episode
+	for rows.Next() {
+		e, err := scanEpisode(rows)
+		if err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, e)
+	}
+	return episodes, rows.Err()
+}
+
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	if buf == nil {
+		return nil
+	}
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,214 @@
+// Package store persists episodes and their embeddings.
+//
+// EpisodeStore is implemented by MongoDB (the production database), SQLite
+// (for running the pipeline on a laptop) and an in-memory map (for tests).
+package store
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+)
+
+// ErrNotFound is returned by Get when no episode has the requested ID.
+var ErrNotFound = errors.New("episode not found")
+
+// Episode is the stored document: a scraped episode plus its embedding.
+type Episode struct {
+	scraper.Episode `bson:",inline"`
+	Embedding       []float32 `bson:"embedding,omitempty" json:"embedding,omitempty"`
+
+	// ContentHash is a hash of the scraped fields, used to skip unchanged
+	// episodes on sync.
+	ContentHash string `bson:"content_hash,omitempty" json:"-"`
+
+	// EmbeddingHash is a hash of the exact text that produced Embedding, and
+	// EmbeddingModel the model that embedded it. Sync re-embeds an episode
+	// only when either differs.
+	EmbeddingHash  string `bson:"embedding_hash,omitempty" json:"-"`
+	EmbeddingModel string `bson:"embedding_model,omitempty" json:"embedding_model,omitempty"`
+}
+
+// Hashes is what sync needs to know about a stored episode.
+type Hashes struct {
+	ContentHash    string `bson:"content_hash"`
+	EmbeddingHash  string `bson:"embedding_hash"`
+	EmbeddingModel string `bson:"embedding_model"`
+}
+
+// Embedding is a vector together with what produced it.
+type Embedding struct {
+	Vector []float32
+	Hash   string // hash of the embedded text
+	Model  string
+}
+
+// UpsertResult counts what Upsert did.
+type UpsertResult struct {
+	Inserted int
+	Updated  int
+}
+
+// ListOptions pages through episodes in ID order.
+type ListOptions struct {
+	// After is the Next cursor of the previous page; "" starts at the
+	// beginning.
+	After string
+
+	// Limit is the page size. Defaults to 100.
+	Limit int
+
+	// Filter restricts which episodes are listed.
+	Filter Filter
+}
+
+// Page is one page of List results.
+type Page struct {
+	Episodes []Episode
+	Next     string // "" on the last page
+}
+
+// SearchOptions configures VectorSearch.
+type SearchOptions struct {
+	// Limit is the number of matches to return. Defaults to 10.
+	Limit int
+
+	// Model restricts the search to vectors from this embedding model.
+	// Episodes without a recorded model predate the field and were embedded
+	// with embedding.DefaultOpenAIModel.
+	Model string
+
+	// Filter restricts which episodes can match.
+	Filter Filter
+}
+
+// Match is a VectorSearch result.
+type Match struct {
+	Episode Episode
+	Score   float64 // cosine similarity, higher is closer
+}
+
+// EpisodeStore is the storage every command works against.
+type EpisodeStore interface {
+	// Get returns the episode with the given ID or ErrNotFound.
+	Get(ctx context.Context, id string) (Episode, error)
+
+	// Upsert writes the scraped fields and hashes of each episode, inserting
+	// it when new. The embedding is written only when Embedding is set;
+	// otherwise the stored one is kept.
+	Upsert(ctx context.Context, episodes []Episode) (UpsertResult, error)
+
+	// List returns a page of the episodes matching opts.Filter, ordered by
+	// ID.
+	List(ctx context.Context, opts ListOptions) (Page, error)
+
+	// SetEmbedding replaces the embedding of one episode.
+	SetEmbedding(ctx context.Context, id string, e Embedding) error
+
+	// VectorSearch returns the episodes whose embeddings are most similar
+	// to vector, best first.
+	VectorSearch(ctx context.Context, vector []float32, opts SearchOptions) ([]Match, error)
+
+	// Hashes maps the ID of every stored episode to its hashes.
+	Hashes(ctx context.Context) (map[string]Hashes, error)
+
+	// Delete removes the given episodes and returns how many existed.
+	Delete(ctx context.Context, ids []string) (int, error)
+
+	// ReplaceGuests replaces every derived guest record with guests.
+	ReplaceGuests(ctx context.Context, guests []Guest) error
+
+	// Guests returns the derived guest records, most appearances first.
+	Guests(ctx context.Context) ([]Guest, error)
+
+	// Close releases the connection.
+	Close(ctx context.Context) error
+}
+
+// TextSearcher is implemented by stores with a native full-text index.
+// Scores are only comparable within one result list.
+type TextSearcher interface {
+	// TextSearch returns the episodes matching the words of query, best
+	// first.
+	TextSearch(ctx context.Context, query string, opts SearchOptions) ([]Match, error)
+}
+
+// ForEach calls fn on every stored episode in ID order, a page at a time.
+func ForEach(ctx context.Context, s EpisodeStore, fn func(Episode) error) error {
+	opts := ListOptions{}
+	for {
+		page, err := s.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, e := range page.Episodes {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if page.Next == "" {
+			return nil
+		}
+		opts.After = page.Next
+	}
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0
+// when they differ in length or either is zero.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// rank scores every candidate against vector in process and returns the
+// best opts.Limit matches. Backends without native vector search use it.
+func rank(candidates []Episode, vector []float32, opts SearchOptions) []Match {
+	var matches []Match
+	for _, e := range candidates {
+		if len(e.Embedding) == 0 || !modelMatches(e, opts.Model) || !opts.Filter.Match(e) {
+			continue
+		}
+		matches = append(matches, Match{Episode: e, Score: CosineSimilarity(vector, e.Embedding)})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if limit := searchLimit(opts); len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func modelMatches(e Episode, model string) bool {
+	if e.EmbeddingModel == "" {
+		return model == "" || model == embedding.DefaultOpenAIModel
+	}
+	return model == "" || e.EmbeddingModel == model
+}
+
+func listLimit(opts ListOptions) int {
+	if opts.Limit <= 0 {
+		return 100
+	}
+	return opts.Limit
+}
+
+func searchLimit(opts SearchOptions) int {
+	if opts.Limit <= 0 {
+		return 10
+	}
+	return opts.Limit
+}
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+)
+
+// backends returns a fresh instance of every store that runs without a
+// server, plus Postgres when TC_TEST_POSTGRES_URL points at a scratch
+// database with pgvector installed. Its episodes table is emptied first.
+func backends(t *testing.T) map[string]EpisodeStore {
+	t.Helper()
+	ctx := context.Background()
+	sqlite, err := OpenSQLite(ctx, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlite.Close(ctx) })
+	stores := map[string]EpisodeStore{
+		"memory": NewMemory(),
+		"sqlite": sqlite,
+	}
+
+	if url := os.Getenv("TC_TEST_POSTGRES_URL"); url != "" {
+		pg, err := OpenPostgres(ctx, url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { pg.Close(ctx) })
+		if _, err := pg.pool.Exec(ctx, `TRUNCATE episodes, guests`); err != nil {
+			t.Fatal(err)
+		}
+		stores["postgres"] = pg
+	}
+	return stores
+}
+
+func testEpisode(id, title string, vector []float32) Episode {
+	return Episode{
+		Episode: scraper.Episode{
+			ID:        id,
+			Url:       "https://example.com/wiki/" + title,
+			Title:     title,
+			EpisodeNo: id,
+			Numbering: scraper.ParseEpisodeNo(id),
+			Date:      "November 15, 2015",
+			Timestamp: time.Date(2015, 11, 15, 0, 0, 0, 0, time.UTC),
+			Guests:    []string{"Jake Longstreth"},
+			Content:   &scraper.TCContentSpec{Name: title},
+		},
+		Embedding:      vector,
+		ContentHash:    "content-" + id,
+		EmbeddingHash:  "text-" + id,
+		EmbeddingModel: "fake-2",
+	}
+}
+
+func TestUpsertAndGet(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			want := testEpisode("1", "Pilot", []float32{1, 0})
+			res, err := s.Upsert(ctx, []Episode{want})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != (UpsertResult{Inserted: 1}) {
+				t.Errorf("first upsert: got %+v", res)
+			}
+
+			got, err := s.Get(ctx, "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v\nwant %+v", got, want)
+			}
+
+			// Without an embedding the stored one is kept.
+			changed := testEpisode("1", "Pilot (Part 1)", nil)
+			changed.ContentHash = "changed"
+			res, err = s.Upsert(ctx, []Episode{changed})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != (UpsertResult{Updated: 1}) {
+				t.Errorf("second upsert: got %+v", res)
+			}
+			got, err = s.Get(ctx, "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "Pilot (Part 1)" || got.ContentHash != "changed" {
+				t.Errorf("content not updated: %+v", got)
+			}
+			if !reflect.DeepEqual(got.Embedding, want.Embedding) || got.EmbeddingHash != want.EmbeddingHash {
+				t.Errorf("embedding not kept: %v %q", got.Embedding, got.EmbeddingHash)
+			}
+
+			if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get(missing): got %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestListPages(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			var episodes []Episode
+			for i := 5; i > 0; i-- {
+				episodes = append(episodes, testEpisode(fmt.Sprint(i), fmt.Sprint("Episode ", i), nil))
+			}
+			if _, err := s.Upsert(ctx, episodes); err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			var pages int
+			opts := ListOptions{Limit: 2}
+			for {
+				page, err := s.List(ctx, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages++
+				for _, e := range page.Episodes {
+					ids = append(ids, e.ID)
+				}
+				if page.Next == "" {
+					break
+				}
+				opts.After = page.Next
+			}
+			if want := []string{"1", "2", "3", "4", "5"}; !reflect.DeepEqual(ids, want) {
+				t.Errorf("got %v, want %v", ids, want)
+			}
+			if pages != 3 {
+				t.Errorf("got %d pages, want 3", pages)
+			}
+		})
+	}
+}
+
+func TestSetEmbeddingAndVectorSearch(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			legacy := testEpisode("3", "Legacy", []float32{0.9, 0.1})
+			legacy.EmbeddingModel = ""
+			episodes := []Episode{
+				testEpisode("1", "East", nil),
+				testEpisode("2", "North", []float32{0, 1}),
+				legacy,
+			}
+			if _, err := s.Upsert(ctx, episodes); err != nil {
+				t.Fatal(err)
+			}
+			err := s.SetEmbedding(ctx, "1", Embedding{Vector: []float32{1, 0}, Hash: "h", Model: "fake-2"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetEmbedding(ctx, "missing", Embedding{}); !errors.Is(err, ErrNotFound) {
+				t.Errorf("SetEmbedding(missing): got %v, want ErrNotFound", err)
+			}
+
+			matches, err := s.VectorSearch(ctx, []float32{1, 0.1}, SearchOptions{Limit: 5, Model: "fake-2"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, m := range matches {
+				ids = append(ids, m.Episode.ID)
+			}
+			// The legacy vector has no model, so it only matches the default.
+			if want := []string{"1", "2"}; !reflect.DeepEqual(ids, want) {
+				t.Errorf("fake-2: got %v, want %v", ids, want)
+			}
+
+			matches, err = s.VectorSearch(ctx, []float32{1, 0.1}, SearchOptions{Limit: 1, Model: embedding.DefaultOpenAIModel})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(matches) != 1 || matches[0].Episode.ID != "3" {
+				t.Errorf("default model: got %+v, want episode 3", matches)
+			}
+		})
+	}
+}
+
+func TestHashesAndDelete(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Upsert(ctx, []Episode{testEpisode("1", "A", []float32{1}), testEpisode("2", "B", nil)}); err != nil {
+				t.Fatal(err)
+			}
+			hashes, err := s.Hashes(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]Hashes{
+				"1": {ContentHash: "content-1", EmbeddingHash: "text-1", EmbeddingModel: "fake-2"},
+				"2": {ContentHash: "content-2"},
+			}
+			if !reflect.DeepEqual(hashes, want) {
+				t.Errorf("got %+v, want %+v", hashes, want)
+			}
+
+			n, err := s.Delete(ctx, []string{"1", "missing"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Errorf("deleted %d, want 1", n)
+			}
+			if _, err := s.Get(ctx, "1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after Delete: got %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgresVectorFormat(t *testing.T) {
+	v := []float32{1, -0.5, 3.25e-7, 0}
+	text := formatVector(v)
+	if text != "[1,-0.5,3.25e-07,0]" {
+		t.Errorf("formatVector: got %s", text)
+	}
+	got, err := parseVector(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("parseVector: got %v, want %v", got, v)
+	}
+	if _, err := parseVector("1,2"); err == nil {
+		t.Error("parseVector accepted a vector without brackets")
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	ctx := context.Background()
+	a := testEpisode("1", "Yacht Rock", nil)
+	b := testEpisode("2", "Corporate Rock", nil)
+	b.Timestamp = time.Date(2016, 1, 10, 0, 0, 0, 0, time.UTC)
+	b.Guests = []string{"Seth Rogen"}
+	b.Notes = "Featuring a yacht-themed Top 5"
+	undated := testEpisode("3", "Lost Episode", nil)
+	undated.Timestamp = time.Time{}
+	undated.EpisodeNo, undated.Numbering = "Special", scraper.ParseEpisodeNo("Special")
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"none", Filter{}, []string{"1", "2", "3"}},
+		{"from", Filter{From: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)}, []string{"2"}},
+		{"to inclusive", Filter{To: time.Date(2015, 11, 15, 0, 0, 0, 0, time.UTC)}, []string{"1"}},
+		{"guest", Filter{Guest: "ROGEN"}, []string{"2"}},
+		{"text in title or notes", Filter{Text: "yacht"}, []string{"1", "2"}},
+		{"text in guests", Filter{Text: "longstreth"}, []string{"1", "3"}},
+		{"combined", Filter{Text: "rock", Guest: "jake"}, []string{"1"}},
+		{"number range", Filter{NumberFrom: 2, NumberTo: 150}, []string{"2"}},
+		{"numbered only", Filter{NumberFrom: 1}, []string{"1", "2"}},
+		{"kind", Filter{Kind: scraper.KindSpecial}, []string{"3"}},
+	}
+	for name, s := range backends(t) {
+		if _, err := s.Upsert(ctx, []Episode{a, b, undated}); err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				page, err := s.List(ctx, ListOptions{Filter: tt.filter})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var ids []string
+				for _, e := range page.Episodes {
+					ids = append(ids, e.ID)
+					if !tt.filter.Match(e) {
+						t.Errorf("listed %s, which Filter.Match rejects", e.ID)
+					}
+				}
+				if !reflect.DeepEqual(ids, tt.want) {
+					t.Errorf("got %v, want %v", ids, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Package storetest provides fixtures for tests of packages that read
+// from an episode store.
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"webscraper/embedding"
+	"webscraper/store"
+)
+
+// Seed embeds the title of each episode with embedder, as if it had been
+// synced under embedder's model, and returns an in-memory store holding
+// them. The episodes are modified in place.
+func Seed(t testing.TB, embedder embedding.Embedder, episodes []store.Episode) *store.Memory {
+	t.Helper()
+	ctx := context.Background()
+	texts := make([]string, len(episodes))
+	for i, e := range episodes {
+		texts[i] = e.Title
+	}
+	vectors, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range episodes {
+		episodes[i].Embedding = vectors[i]
+		episodes[i].EmbeddingModel = embedder.Model()
+	}
+	s := store.NewMemory()
+	if _, err := s.Upsert(ctx, episodes); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
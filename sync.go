@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+	"webscraper/store"
+)
+
+// syncReport counts what a sync did to the store.
+type syncReport struct {
+	Inserted   int
+	Updated    int // stored episodes whose content changed
+	Reembedded int // stored episodes given a new embedding, content unchanged
+	Unchanged  int
+	Removed    int
+	Stale      int // stored but gone from the wiki, not pruned
+	Embedded   int // episodes given a new embedding
+	Failed     int
+}
+
+func (r syncReport) String() string {
+	s := fmt.Sprintf("inserted %d, updated %d, unchanged %d, removed %d, embedded %d",
+		r.Inserted, r.Updated, r.Unchanged, r.Removed, r.Embedded)
+	if r.Reembedded > 0 {
+		s += fmt.Sprintf(", re-embedded %d", r.Reembedded)
+	}
+	if r.Stale > 0 {
+		s += fmt.Sprintf(", stale %d", r.Stale)
+	}
+	if r.Failed > 0 {
+		s += fmt.Sprintf(", failed %d", r.Failed)
+	}
+	return s
+}
+
+// syncOptions configures syncEpisodes.
+type syncOptions struct {
+	// embedder generates the embedding for an episode's embeddingText.
+	// Changing its model re-embeds every episode on the next sync.
+	embedder embedding.Embedder
+
+	// prune deletes stored episodes that are no longer on the wiki.
+	prune bool
+}
+
+// syncEpisodes upserts the scraped episodes in a single batch. Only
+// episodes whose content hash differs from the stored one are written, and
+// only episodes whose embedding text or model changed are re-embedded.
+func syncEpisodes(ctx context.Context, s store.EpisodeStore, scraped []scraper.Episode, opts syncOptions) (syncReport, error) {
+	var report syncReport
+
+	existing, err := s.Hashes(ctx)
+	if err != nil {
+		return report, err
+	}
+	scraped, err = keepStoredContent(ctx, s, scraped, existing)
+	if err != nil {
+		return report, err
+	}
+
+	// First decide what each episode needs, collecting every text that has
+	// to be embedded so they can go out in as few requests as possible.
+	type change struct {
+		episode        store.Episode
+		stored         bool
+		contentChanged bool
+		text           int // index into texts, or -1
+	}
+	var changes []change
+	var texts []string
+
+	seen := make(map[string]bool, len(scraped))
+	for _, sc := range scraped {
+		if seen[sc.ID] {
+			fmt.Printf("⚠️ Duplicate episode %s (#%s %s), keeping the first row\n", sc.ID, sc.EpisodeNo, sc.Title)
+			continue
+		}
+		seen[sc.ID] = true
+
+		e := store.Episode{Episode: sc, ContentHash: contentHash(sc)}
+		text := embeddingText(e)
+		old, stored := existing[e.ID]
+		contentChanged := !stored || old.ContentHash != e.ContentHash
+		embeddingStale := !stored || old.EmbeddingHash != textHash(text) || old.EmbeddingModel != opts.embedder.Model()
+		if !contentChanged && !embeddingStale {
+			report.Unchanged++
+			continue
+		}
+
+		c := change{episode: e, stored: stored, contentChanged: contentChanged, text: -1}
+		if embeddingStale {
+			c.text = len(texts)
+			texts = append(texts, text)
+		}
+		changes = append(changes, c)
+	}
+
+	var vectors [][]float32
+	if len(texts) > 0 {
+		var batchErr *embedding.BatchError
+		vectors, err = opts.embedder.Embed(ctx, texts)
+		if err != nil && !errors.As(err, &batchErr) {
+			return report, err
+		}
+		report.Embedded = len(texts)
+		if batchErr != nil {
+			report.Embedded -= len(batchErr.Failed)
+			for i, err := range batchErr.Failed {
+				fmt.Printf("❌ Failed to generate embedding for %.60q: %v\n", texts[i], err)
+			}
+		}
+	}
+
+	// Count from what is written rather than from Upsert's result: Mongo
+	// reports rewriting an identical document as no modification.
+	var upserts []store.Episode
+	var inserted, updated, reembedded int
+	for _, c := range changes {
+		if c.text >= 0 {
+			switch {
+			case vectors[c.text] == nil && !c.stored:
+				// Leave it out entirely so the next run treats it as new.
+				report.Failed++
+				continue
+			case vectors[c.text] == nil && !c.contentChanged:
+				// Nothing to write; the next run retries the embedding.
+				report.Failed++
+				report.Unchanged++
+				continue
+			case vectors[c.text] == nil:
+				// Keep the old embedding and hash so the next run retries.
+				report.Failed++
+			default:
+				c.episode.Embedding = vectors[c.text]
+				c.episode.EmbeddingHash = textHash(texts[c.text])
+				c.episode.EmbeddingModel = opts.embedder.Model()
+			}
+		}
+		switch {
+		case !c.stored:
+			inserted++
+		case c.contentChanged:
+			updated++
+		default:
+			reembedded++
+		}
+		upserts = append(upserts, c.episode)
+	}
+
+	if _, err := s.Upsert(ctx, upserts); err != nil {
+		return report, err
+	}
+	report.Inserted, report.Updated, report.Reembedded = inserted, updated, reembedded
+
+	var gone []string
+	for id := range existing {
+		if !seen[id] {
+			gone = append(gone, id)
+		}
+	}
+	if !opts.prune {
+		report.Stale = len(gone)
+		return report, nil
+	}
+	report.Removed, err = s.Delete(ctx, gone)
+	return report, err
+}
+
+// keepStoredContent fills in the page content of scraped episodes whose
+// page was not crawled (-pages=false or a failed fetch) from the stored
+// episode, so that a sync without pages neither counts them as changed nor
+// wipes their content. The stored episodes are only read when needed.
+func keepStoredContent(ctx context.Context, s store.EpisodeStore, scraped []scraper.Episode, existing map[string]store.Hashes) ([]scraper.Episode, error) {
+	missing := make(map[string]bool)
+	for _, e := range scraped {
+		if _, stored := existing[e.ID]; stored && e.Content == nil {
+			missing[e.ID] = true
+		}
+	}
+	if len(missing) == 0 {
+		return scraped, nil
+	}
+
+	content := make(map[string]*scraper.TCContentSpec)
+	err := store.ForEach(ctx, s, func(e store.Episode) error {
+		if missing[e.ID] && e.Content != nil {
+			content[e.ID] = e.Content
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	filled := make([]scraper.Episode, len(scraped))
+	for i, e := range scraped {
+		if e.Content == nil {
+			e.Content = content[e.ID]
+		}
+		filled[i] = e
+	}
+	return filled, nil
+}
+
+// contentHash identifies the scraped content of an episode.
+func contentHash(e scraper.Episode) string {
+	data, _ := json.Marshal(e)
+	return textHash(string(data))
+}
+
+// textHash identifies the exact text that was embedded.
+func textHash(text string) string {
+	hash := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(hash[:])
+}
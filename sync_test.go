@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"webscraper/embedding"
+	"webscraper/scraper"
+	"webscraper/store"
+)
+
+// countingEmbedder is a fake embedder that counts the texts it embeds.
+type countingEmbedder struct {
+	*embedding.Fake
+	calls, texts int
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.calls++
+	c.texts += len(texts)
+	return c.Fake.Embed(ctx, texts)
+}
+
+// failingEmbedder fails every text under a model of its own.
+type failingEmbedder struct{}
+
+func (failingEmbedder) Model() string { return "failing" }
+
+func (failingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	failed := make(map[int]error, len(texts))
+	for i := range texts {
+		failed[i] = errors.New("rejected")
+	}
+	return make([][]float32, len(texts)), &embedding.BatchError{Failed: failed, Total: len(texts)}
+}
+
+func scrapedEpisode(id, title string) scraper.Episode {
+	return scraper.Episode{
+		ID:        id,
+		Url:       "https://example.com/wiki/" + title,
+		Title:     title,
+		EpisodeNo: id,
+		Content:   &scraper.TCContentSpec{Name: title, Episode: scraper.TCEpisodeSpec{Topics: "Topics of " + title}},
+	}
+}
+
+func TestSyncKeepsContentWhenPagesAreNotCrawled(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+	embedder := &countingEmbedder{Fake: embedding.NewFake(8)}
+
+	crawled := []scraper.Episode{scrapedEpisode("1", "Pilot"), scrapedEpisode("2", "Sequel")}
+	if _, err := syncEpisodes(ctx, s, crawled, syncOptions{embedder: embedder}); err != nil {
+		t.Fatal(err)
+	}
+
+	// As with -pages=false or failed page fetches: no Content.
+	guideOnly := []scraper.Episode{scrapedEpisode("1", "Pilot"), scrapedEpisode("2", "Sequel (Part 2)")}
+	for i := range guideOnly {
+		guideOnly[i].Content = nil
+	}
+	report, err := syncEpisodes(ctx, s, guideOnly, syncOptions{embedder: embedder})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Unchanged != 1 || report.Updated != 1 {
+		t.Errorf("got %s, want episode 1 unchanged and 2 updated", report)
+	}
+
+	for _, want := range crawled {
+		got, err := s.Get(ctx, want.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Content == nil || got.Content.Episode.Topics != want.Content.Episode.Topics {
+			t.Errorf("episode %s content = %+v, want it kept", want.ID, got.Content)
+		}
+	}
+}
+
+func TestSyncEpisodes(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory()
+	embedder := &countingEmbedder{Fake: embedding.NewFake(8)}
+	scraped := []scraper.Episode{scrapedEpisode("1", "Pilot"), scrapedEpisode("2", "Sequel"), scrapedEpisode("3", "Finale")}
+
+	sync := func(scraped []scraper.Episode, opts syncOptions) syncReport {
+		t.Helper()
+		report, err := syncEpisodes(ctx, s, scraped, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	report := sync(scraped, syncOptions{embedder: embedder})
+	if want := (syncReport{Inserted: 3, Embedded: 3}); report != want {
+		t.Errorf("first sync: got %s, want %s", report, want)
+	}
+	if embedder.calls != 1 || embedder.texts != 3 {
+		t.Errorf("first sync: embedded %d texts in %d calls, want 3 in 1", embedder.texts, embedder.calls)
+	}
+
+	embedder.calls, embedder.texts = 0, 0
+	report = sync(scraped, syncOptions{embedder: embedder})
+	if want := (syncReport{Unchanged: 3}); report != want {
+		t.Errorf("identical sync: got %s, want %s", report, want)
+	}
+	if embedder.calls != 0 {
+		t.Errorf("identical sync: made %d embed calls, want 0", embedder.calls)
+	}
+
+	// A new title changes the embedding text; new page topics only the
+	// content.
+	changed := append([]scraper.Episode(nil), scraped...)
+	changed[0].Title = "Pilot (Remastered)"
+	content := *changed[1].Content
+	content.Episode.Topics = "First appearance of the bell."
+	changed[1].Content = &content
+	report = sync(changed, syncOptions{embedder: embedder})
+	if want := (syncReport{Updated: 2, Unchanged: 1, Embedded: 1}); report != want {
+		t.Errorf("edited sync: got %s, want %s", report, want)
+	}
+
+	// A different model re-embeds everything, even unchanged episodes.
+	other := &countingEmbedder{Fake: embedding.NewFake(16)}
+	report = sync(changed, syncOptions{embedder: other})
+	if want := (syncReport{Reembedded: 3, Embedded: 3}); report != want {
+		t.Errorf("new model: got %s, want %s", report, want)
+	}
+	for _, e := range changed {
+		got, err := s.Get(ctx, e.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.EmbeddingModel != other.Model() || len(got.Embedding) != 16 {
+			t.Errorf("episode %s: model %q with %d dimensions, want %q with 16", e.ID, got.EmbeddingModel, len(got.Embedding), other.Model())
+		}
+	}
+
+	// When embedding fails, episodes whose content didn't change are left
+	// alone with their old embedding.
+	report = sync(changed, syncOptions{embedder: failingEmbedder{}})
+	if want := (syncReport{Unchanged: 3, Failed: 3}); report != want {
+		t.Errorf("failed embeddings: got %s, want %s", report, want)
+	}
+	if got, err := s.Get(ctx, "1"); err != nil || got.EmbeddingModel != other.Model() {
+		t.Errorf("episode 1 after failed embeddings: model %q, err %v; want %q kept", got.EmbeddingModel, err, other.Model())
+	}
+
+	// Episode 3 is gone from the wiki: stale without -prune, removed with.
+	report = sync(changed[:2], syncOptions{embedder: other})
+	if want := (syncReport{Unchanged: 2, Stale: 1}); report != want {
+		t.Errorf("without prune: got %s, want %s", report, want)
+	}
+	report = sync(changed[:2], syncOptions{embedder: other, prune: true})
+	if want := (syncReport{Unchanged: 2, Removed: 1}); report != want {
+		t.Errorf("with prune: got %s, want %s", report, want)
+	}
+	if _, err := s.Get(ctx, "3"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("pruned episode: Get error = %v, want ErrNotFound", err)
+	}
+}